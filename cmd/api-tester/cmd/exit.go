@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/gaoyong06/api-tester/internal/runner"
+)
+
+// run 命令的退出码，所有测试通过或失败率未超过 ci.fail_threshold 时退出码为 0
+const (
+	// 测试失败率超过 ci.fail_threshold，或超过 --deadline 后没有全部完成
+	exitTestFailure = 1
+	// 配置错误：配置文件、规范文件或命令行参数无效
+	exitConfigError = 2
+	// 运行环境错误：无法创建输出目录、无法生成报告等
+	exitInfraError = 3
+	// 运行被中断（Ctrl-C 或 SIGTERM），与 shell 中 SIGINT 的退出码一致
+	exitInterrupted = 130
+)
+
+// fatalf 输出错误信息并以指定的退出码退出
+func fatalf(code int, format string, args ...interface{}) {
+	log.Printf(format, args...)
+	os.Exit(code)
+}
+
+// runErrorCode 根据测试运行的错误类型返回退出码
+func runErrorCode(err error) int {
+	var configErr *runner.ConfigError
+	if errors.As(err, &configErr) {
+		return exitConfigError
+	}
+	return exitInfraError
+}
@@ -42,6 +42,7 @@ u53efu4ee5u4f7fu7528u5df2u4fddu5b58u7684u6d4bu8bd5u7ed3u679cu6587u4ef6u6216u8005
 
 		// u52a0u8f7du6d4bu8bd5u7ed3u679c
 		var results []*types.EndpointTestResult
+		var environment string
 		var err error
 
 		if resultsFile != "" {
@@ -52,6 +53,7 @@ u53efu4ee5u4f7fu7528u5df2u4fddu5b58u7684u6d4bu8bd5u7ed3u679cu6587u4ef6u6216u8005
 			}
 			// 使用测试结果中的详细结果
 			results = testResult.Results
+			environment = testResult.Environment
 		} else {
 			// u4eceu914du7f6eu6587u4ef6u4e2du6307u5b9au7684u6700u65b0u6d4bu8bd5u7ed3u679cu52a0u8f7d
 			// u8fd9u91ccu53efu4ee5u5b9eu73b0u4eceu914du7f6eu6587u4ef6u4e2du6307u5b9au7684u76eeu5f55u627eu5230u6700u65b0u7684u6d4bu8bd5u7ed3u679cu6587u4ef6
@@ -65,6 +67,7 @@ u53efu4ee5u4f7fu7528u5df2u4fddu5b58u7684u6d4bu8bd5u7ed3u679cu6587u4ef6u6216u8005
 			}
 			// 使用测试结果中的详细结果
 			results = testResult.Results
+			environment = testResult.Environment
 		}
 
 		// u52a0u8f7d API u5b9au4e49
@@ -93,11 +96,11 @@ u53efu4ee5u4f7fu7528u5df2u4fddu5b58u7684u6d4bu8bd5u7ed3u679cu6587u4ef6u6216u8005
 		// u6839u636eu62a5u544au7c7bu578bu751fu6210u4e0du540cu683cu5f0fu7684u62a5u544a
 		switch reportType {
 		case "json":
-			reportPath, err = machine.GenerateReport(apiDef, results, reportOutputDir, "json")
+			reportPath, err = machine.GenerateReport(apiDef, results, reportOutputDir, "json", environment)
 		case "xml":
-			reportPath, err = machine.GenerateReport(apiDef, results, reportOutputDir, "xml")
+			reportPath, err = machine.GenerateReport(apiDef, results, reportOutputDir, "xml", environment)
 		case "junit":
-			reportPath, err = machine.GenerateJUnitReport(apiDef, results, reportOutputDir)
+			reportPath, err = machine.GenerateJUnitReport(apiDef, results, reportOutputDir, environment)
 		case "html":
 			// u8fd9u91ccu53efu4ee5u8c03u7528HTMLu62a5u544au751fu6210u5668
 			// u5f53u524du7b80u5316u5904u7406uff0cu76f4u63a5u8f93u51fau9519u8befu4fe1u606f
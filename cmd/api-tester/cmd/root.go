@@ -35,7 +35,7 @@ func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exitConfigError)
 	}
 }
 
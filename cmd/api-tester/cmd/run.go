@@ -1,16 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/gaoyong06/api-tester/internal/config"
 	"github.com/gaoyong06/api-tester/internal/config/yaml"
 	"github.com/gaoyong06/api-tester/internal/parser"
 	"github.com/gaoyong06/api-tester/internal/reporter/machine"
 	"github.com/gaoyong06/api-tester/internal/runner"
+	"github.com/gaoyong06/api-tester/internal/scenario"
 	"github.com/spf13/cobra"
 )
 
@@ -22,7 +27,21 @@ var (
 	timeout       int
 	pathParams    string
 	requestBodies string
-	scenarioFile  string
+	parallel      int
+	failFast      bool
+	maxFailures   int
+	deadline      time.Duration
+	envName       string
+	// 场景和步骤过滤条件
+	scenarioPatterns []string
+	stepPatterns     []string
+	tags             []string
+	excludeTags      []string
+	// 只重新运行结果文件中失败的步骤
+	rerunFailed string
+	// 严格模式和只检查配置
+	strict bool
+	check  bool
 )
 
 // runCmd 表示 run 子命令
@@ -39,12 +58,37 @@ var runCmd = &cobra.Command{
 		var err error
 		outputFlag := cmd.Flags().Lookup("output")
 		outputFlagChanged := outputFlag != nil && outputFlag.Changed
+		// 报告类型：命令行参数优先，其次使用配置文件中的 ci.output_format
+		effectiveReportType := reportType
+		// 允许的最大失败率（百分比）
+		failThreshold := 0.0
 
 		if cfgFile != "" {
 			// 从配置文件加载配置
 			yamlConfig, err := yaml.LoadConfig(cfgFile)
 			if err != nil {
-				log.Fatalf("无法加载配置文件: %v", err)
+				fatalf(exitConfigError, "无法加载配置文件: %v", err)
+			}
+			// 使用选择的环境覆盖全局配置
+			if err := yamlConfig.ApplyEnvironment(envName); err != nil {
+				fatalf(exitConfigError, "环境配置错误: %v", err)
+			}
+			if envName != "" {
+				fmt.Printf("使用环境: %s (%s)\n", envName, yamlConfig.BaseURL)
+			}
+			if strict {
+				yamlConfig.Strict = true
+			}
+			// 只静态检查配置中的变量引用，不运行测试
+			if check {
+				os.Exit(checkConfig(yamlConfig))
+			}
+			if yamlConfig.CI.FailThreshold < 0 || yamlConfig.CI.FailThreshold > 100 {
+				fatalf(exitConfigError, "ci.fail_threshold 必须在 0 到 100 之间: %v", yamlConfig.CI.FailThreshold)
+			}
+			failThreshold = yamlConfig.CI.FailThreshold
+			if !cmd.Flags().Changed("report-type") && yamlConfig.CI.OutputFormat != "" {
+				effectiveReportType = yamlConfig.CI.OutputFormat
 			}
 
 			// 解析 spec 文件路径（相对于配置文件目录）
@@ -123,34 +167,70 @@ var runCmd = &cobra.Command{
 			if specFile == "" || baseURL == "" {
 				cmd.Help()
 				fmt.Println("\n错误: 必须提供 spec 和 url 参数，或者使用配置文件")
-				os.Exit(1)
+				os.Exit(exitConfigError)
+			}
+			if envName != "" {
+				fatalf(exitConfigError, "--env 需要在配置文件中定义 environments")
+			}
+			if check {
+				fatalf(exitConfigError, "--check 需要指定配置文件")
 			}
 
 			// 从命令行参数创建配置
 			cfg, err = config.NewConfig(specFile, baseURL, headers, effectiveOutputDir, verbose, timeout, pathParams, requestBodies)
 			if err != nil {
-				log.Fatalf("配置错误: %v", err)
+				fatalf(exitConfigError, "配置错误: %v", err)
 			}
 		}
 
 		if cfg.OutputDir == "" {
 			cfg.OutputDir = "./reports"
 		}
+		cfg.Parallel = parallel
+		cfg.MaxFailures = maxFailures
+		if failFast && maxFailures == 0 {
+			cfg.MaxFailures = 1
+		}
+		cfg.Deadline = deadline
+		cfg.Environment = envName
+		cfg.ScenarioPatterns = scenarioPatterns
+		cfg.StepPatterns = stepPatterns
+		cfg.Tags = tags
+		cfg.ExcludeTags = excludeTags
+		cfg.RerunFailed = rerunFailed
+
+		// 第一次中断（Ctrl-C）时取消运行：中止正在发送的请求，执行清理步骤并用已有结果生成报告；
+		// 之后恢复默认的信号处理，再次中断将立即退出
+		ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stopSignals()
+		finished := make(chan struct{})
+		defer close(finished)
+		go func() {
+			select {
+			case <-ctx.Done():
+				stopSignals()
+				fmt.Println("\n收到中断信号，停止执行剩余步骤，正在运行清理步骤并生成报告（再次中断将立即退出）")
+			case <-finished:
+			}
+		}()
 
 		// 创建并运行测试
 		r := runner.NewRunner(cfg)
-		results, err := r.Run()
+		results, err := r.Run(ctx)
 		if err != nil {
-			log.Fatalf("测试运行失败: %v", err)
+			fatalf(runErrorCode(err), "测试运行失败: %v", err)
 		}
 
 		// 输出测试结果摘要
-		fmt.Printf("\n测试完成! 总计: %d, 通过: %d, 失败: %d\n",
-			results.Total, results.Passed, results.Failed)
+		fmt.Printf("\n测试完成! 总计: %d, 通过: %d, 失败: %d, 跳过: %d\n",
+			results.Total, results.Passed, results.Failed, results.Skipped)
+		if results.Flaky > 0 {
+			fmt.Printf("其中 %d 个测试自动重试后才通过，已标记为不稳定\n", results.Flaky)
+		}
 		fmt.Printf("详细报告已保存到: %s\n", results.ReportPath)
 
 		// 如果需要生成机器可读报告
-		if reportType == "json" || reportType == "xml" || reportType == "junit" {
+		if effectiveReportType == "json" || effectiveReportType == "xml" || effectiveReportType == "junit" {
 			// 解析API定义
 			var apiDef *parser.APIDefinition
 
@@ -158,7 +238,7 @@ var runCmd = &cobra.Command{
 			if cfg.SpecFile != "" {
 				apiDef, err = parser.ParseSwaggerFile(cfg.SpecFile)
 				if err != nil {
-					log.Fatalf("无法解析API定义: %v", err)
+					fatalf(exitConfigError, "无法解析API定义: %v", err)
 				}
 			} else if len(cfg.SpecFiles) > 0 {
 				// 如果指定了多个规范文件，合并所有文件的信息
@@ -187,16 +267,16 @@ var runCmd = &cobra.Command{
 
 				// 检查是否成功解析了任何端点
 				if len(apiDef.Endpoints) == 0 {
-					log.Fatalf("无法从任何规范文件中解析出端点")
+					fatalf(exitConfigError, "无法从任何规范文件中解析出端点")
 				}
 			} else {
-				log.Fatalf("未指定API规范文件")
+				fatalf(exitConfigError, "未指定API规范文件")
 			}
 
 			// 确保输出目录存在
 			outputDir := cfg.OutputDir
 			if err := os.MkdirAll(outputDir, 0755); err != nil {
-				log.Fatalf("无法创建输出目录: %v", err)
+				fatalf(exitInfraError, "无法创建输出目录: %v", err)
 			}
 
 			// 将测试结果转换为端点测试结果数组，用于报告生成
@@ -210,7 +290,7 @@ var runCmd = &cobra.Command{
 
 			// 始终生成 JSON 报告，方便机器处理
 			fmt.Println("正在生成 JSON 报告...")
-			jsonReportPath, err := machine.GenerateReport(apiDef, endpointResults, outputDir, "json")
+			jsonReportPath, err := machine.GenerateReport(apiDef, endpointResults, outputDir, "json", results.Environment)
 			if err != nil {
 				log.Printf("警告: 无法生成 JSON 报告: %v", err)
 			} else {
@@ -225,29 +305,44 @@ var runCmd = &cobra.Command{
 			// 根据报告类型生成其他格式的报告
 			var reportPath string
 
-			switch reportType {
+			switch effectiveReportType {
 			case "json":
 				// 已经生成了 JSON 报告，不需要重复生成
 				reportPath = jsonReportPath
 			case "xml":
-				reportPath, err = machine.GenerateReport(apiDef, endpointResults, outputDir, "xml")
+				reportPath, err = machine.GenerateReport(apiDef, endpointResults, outputDir, "xml", results.Environment)
 			case "junit":
-				reportPath, err = machine.GenerateJUnitReport(apiDef, endpointResults, outputDir)
+				reportPath, err = machine.GenerateJUnitReport(apiDef, endpointResults, outputDir, results.Environment)
 			default:
 				// 默认生成 HTML 报告
-				reportPath, err = machine.GenerateReport(apiDef, endpointResults, outputDir, "html")
+				reportPath, err = machine.GenerateReport(apiDef, endpointResults, outputDir, "html", results.Environment)
 			}
 
 			if err != nil {
-				log.Fatalf("无法生成机器可读报告: %v", err)
+				fatalf(exitInfraError, "无法生成机器可读报告: %v", err)
 			}
 
 			fmt.Printf("机器可读报告已保存到: %s\n", reportPath)
+		}
+
+		// 运行被中断或超过截止时间时测试没有全部完成，不能视为通过
+		if ctx.Err() != nil {
+			fmt.Println("运行被中断，只生成了部分结果的报告")
+			os.Exit(exitInterrupted)
+		}
+		if results.StopReason != "" {
+			fmt.Printf("运行未完成 (%s)，只生成了部分结果的报告\n", results.StopReason)
+			os.Exit(exitTestFailure)
+		}
 
-			// 如果有测试失败，返回非零退出码
-			if results.Failed > 0 {
-				os.Exit(1)
+		// 失败率超过阈值时返回非零退出码，与报告类型无关
+		if results.Failed > 0 {
+			failRate := results.FailRate()
+			if failRate > failThreshold {
+				fmt.Printf("失败率 %.2f%% 超过阈值 %.2f%%\n", failRate, failThreshold)
+				os.Exit(exitTestFailure)
 			}
+			fmt.Printf("失败率 %.2f%% 未超过阈值 %.2f%%\n", failRate, failThreshold)
 		}
 	},
 }
@@ -261,6 +356,21 @@ func convertStringMapToInterfaceMap(strMap map[string]string) map[string]interfa
 	return result
 }
 
+// checkConfig 静态检查配置中引用的变量，打印发现的问题并返回退出码
+func checkConfig(yamlConfig *yaml.Config) int {
+	problems := scenario.Check(yamlConfig)
+	if len(problems) == 0 {
+		fmt.Println("检查通过: 所有引用的变量和路径参数都有定义")
+		return 0
+	}
+
+	fmt.Printf("检查发现 %d 个问题:\n", len(problems))
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+	return exitConfigError
+}
+
 func init() {
 	rootCmd.AddCommand(runCmd)
 
@@ -271,5 +381,16 @@ func init() {
 	runCmd.Flags().IntVar(&timeout, "timeout", 30, "请求超时时间 (秒)")
 	runCmd.Flags().StringVar(&pathParams, "path-params", "", "路径参数文件 (JSON 格式)")
 	runCmd.Flags().StringVar(&requestBodies, "request-bodies", "", "请求体模板文件 (JSON 格式)")
-	runCmd.Flags().StringVar(&scenarioFile, "scenario", "", "测试场景文件 (YAML 格式)")
+	runCmd.Flags().StringSliceVar(&scenarioPatterns, "scenario", nil, "只运行名称匹配的场景，支持通配符，可多次指定或用逗号分隔")
+	runCmd.Flags().StringSliceVar(&stepPatterns, "step", nil, "只运行名称匹配的步骤及其依赖的步骤，支持通配符")
+	runCmd.Flags().StringSliceVar(&tags, "tag", nil, "只运行带有匹配标签的场景，支持通配符")
+	runCmd.Flags().StringSliceVar(&excludeTags, "exclude-tag", nil, "排除带有匹配标签的场景，支持通配符")
+	runCmd.Flags().StringVar(&rerunFailed, "rerun-failed", "", "只重新运行结果文件（JSON 报告）中失败的步骤及其依赖的步骤")
+	runCmd.Flags().BoolVar(&strict, "strict", false, "严格模式：变量无法解析、路径参数未替换或提取变量失败时步骤失败（覆盖配置文件中的 strict）")
+	runCmd.Flags().BoolVar(&check, "check", false, "只检查配置中引用的变量和路径参数是否都有定义，不运行测试")
+	runCmd.Flags().IntVar(&parallel, "parallel", 1, "并发运行的最大场景数")
+	runCmd.Flags().BoolVar(&failFast, "fail-fast", false, "第一个步骤失败后停止运行剩余的步骤和场景（清理步骤仍会执行）")
+	runCmd.Flags().IntVar(&maxFailures, "max-failures", 0, "失败的步骤数达到该值后停止运行，0 表示不限制")
+	runCmd.Flags().StringVar(&envName, "env", "", "使用配置文件 environments 中的环境（如 staging）")
+	runCmd.Flags().DurationVar(&deadline, "deadline", 0, "整个运行的截止时间（如 10m），超过后停止运行剩余的测试，0 表示不限制")
 }
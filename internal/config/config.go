@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gaoyong06/api-tester/internal/config/yaml"
 )
@@ -31,6 +32,24 @@ type Config struct {
 	RequestBodies map[string]interface{}
 	// YAML 配置文件对象，包含测试场景定义
 	YamlConfig *yaml.Config
+	// 并发运行的最大场景数
+	Parallel int
+	// 失败多少个步骤后停止运行，0 表示不限制
+	MaxFailures int
+	// 整个运行的截止时间，超过后停止运行剩余的测试，0 表示不限制
+	Deadline time.Duration
+	// 选择的环境名称（配置文件 environments 中的键），为空表示未选择环境
+	Environment string
+	// 场景名称过滤（支持通配符）
+	ScenarioPatterns []string
+	// 步骤名称过滤（支持通配符）
+	StepPatterns []string
+	// 场景标签过滤（支持通配符）
+	Tags []string
+	// 排除的场景标签（支持通配符）
+	ExcludeTags []string
+	// 上次运行的结果文件，只重新运行其中失败的步骤
+	RerunFailed string
 }
 
 // NewConfig 创建新的配置
@@ -0,0 +1,104 @@
+package yaml
+
+import (
+	"fmt"
+)
+
+// validate 检查认证类型和该类型必填的字段
+func (a *AuthConfig) validate() error {
+	switch a.Type {
+	case "none":
+	case "basic":
+		if a.Username == "" {
+			return fmt.Errorf("basic 认证缺少 username")
+		}
+	case "bearer":
+		if a.Token == "" {
+			return fmt.Errorf("bearer 认证缺少 token")
+		}
+	case "api_key":
+		if a.Name == "" || a.Value == "" {
+			return fmt.Errorf("api_key 认证缺少 name 或 value")
+		}
+		switch a.In {
+		case "", "header", "query", "cookie":
+		default:
+			return fmt.Errorf("api_key 认证的 in 必须是 header、query 或 cookie，实际为 %s", a.In)
+		}
+	case "oauth2_client_credentials", "oauth2_password":
+		if a.TokenURL == "" {
+			return fmt.Errorf("%s 认证缺少 token_url", a.Type)
+		}
+		if a.Type == "oauth2_client_credentials" && a.ClientID == "" {
+			return fmt.Errorf("%s 认证缺少 client_id", a.Type)
+		}
+		if a.Type == "oauth2_password" && a.Username == "" {
+			return fmt.Errorf("%s 认证缺少 username", a.Type)
+		}
+		switch a.ClientAuth {
+		case "", "header", "body":
+		default:
+			return fmt.Errorf("client_auth 必须是 header 或 body，实际为 %s", a.ClientAuth)
+		}
+	case "":
+		return fmt.Errorf("缺少认证类型 type")
+	default:
+		return fmt.Errorf("不支持的认证类型 %s，可用的类型: basic、bearer、api_key、oauth2_client_credentials、oauth2_password、none", a.Type)
+	}
+	return nil
+}
+
+// validateAuth 检查全局、场景、步骤和步骤模板中的认证配置
+func validateAuth(config *Config) error {
+	if err := checkAuth("全局", config.Auth); err != nil {
+		return err
+	}
+	if err := validateStepsAuth("before_all", config.BeforeAll); err != nil {
+		return err
+	}
+	if err := validateStepsAuth("after_all", config.AfterAll); err != nil {
+		return err
+	}
+	for name, template := range config.StepTemplates {
+		if err := validateStepsAuth("步骤模板 "+name, template.Steps); err != nil {
+			return err
+		}
+	}
+	for _, scenario := range config.Scenarios {
+		location := "场景 " + scenario.Name
+		if err := checkAuth(location, scenario.Auth); err != nil {
+			return err
+		}
+		for _, steps := range [][]Step{scenario.Setup, scenario.Steps, scenario.Teardown} {
+			if err := validateStepsAuth(location, steps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateStepsAuth 检查一组步骤（包括嵌套步骤）的认证配置
+func validateStepsAuth(location string, steps []Step) error {
+	for _, step := range steps {
+		stepLocation := fmt.Sprintf("%s 步骤 %s", location, step.Name)
+		if err := checkAuth(stepLocation, step.Auth); err != nil {
+			return err
+		}
+		if err := validateStepsAuth(stepLocation+" >", step.Steps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAuth 检查单个认证配置，auth 为 nil 时表示未配置
+func checkAuth(location string, auth *AuthConfig) error {
+	if auth == nil {
+		return nil
+	}
+	if err := auth.validate(); err != nil {
+		return fmt.Errorf("%s 的认证配置无效: %v", location, err)
+	}
+	return nil
+}
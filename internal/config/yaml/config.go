@@ -4,6 +4,9 @@ package yaml
 type Config struct {
 	// 包含的其他配置文件路径
 	Includes []string `yaml:"includes"`
+	// .env 文件路径（相对于配置文件所在目录），其中的变量可以在配置中用 ${NAME} 引用，
+	// 已经设置的环境变量不会被覆盖
+	EnvFile string `yaml:"env_file"`
 	// API规范文件路径（单个文件，向后兼容）
 	Spec string `yaml:"spec"`
 	// API规范文件路径（多个文件）
@@ -14,12 +17,24 @@ type Config struct {
 	OutputDir string `yaml:"output_dir"`
 	// 超时时间（秒）
 	Timeout int `yaml:"timeout"`
+	// 请求遇到网络错误或 retry_on 中的状态码时的自动重试次数
+	Retries int `yaml:"retries"`
+	// 触发自动重试的状态码，默认 502、503、504；包含 network_error 时非幂等请求也重试网络错误
+	RetryOn *RetryOn `yaml:"retry_on"`
 	// 是否详细输出
 	Verbose bool `yaml:"verbose"`
 	// 默认值配置
 	DefaultValues map[string]string `yaml:"default_values"`
 	// 全局变量
 	Variables map[string]interface{} `yaml:"variables"`
+	// 变量不存在时是否尝试驼峰/下划线形式和不区分大小写的相似名称（如 {{.user_id}} 使用 userId 的值）
+	FuzzyVariables bool `yaml:"fuzzy_variables"`
+	// 严格模式：变量无法解析、路径参数未替换或提取变量失败时步骤失败，不使用内置的默认值
+	Strict bool `yaml:"strict"`
+	// 全局认证配置，场景和步骤可以覆盖
+	Auth *AuthConfig `yaml:"auth"`
+	// 环境配置，通过 --env 选择，选中的环境覆盖全局的 base_url、请求头、变量和超时时间
+	Environments map[string]Environment `yaml:"environments"`
 
 	// 请求配置
 	Request struct {
@@ -45,6 +60,12 @@ type Config struct {
 
 	// 测试场景配置
 	Scenarios []Scenario `yaml:"scenarios"`
+	// 可复用的步骤模板，步骤通过 use 引用
+	StepTemplates map[string]StepTemplate `yaml:"step_templates"`
+	// 所有场景运行前执行的步骤，失败时跳过所有场景
+	BeforeAll []Step `yaml:"before_all"`
+	// 所有场景运行后执行的步骤，总是执行
+	AfterAll []Step `yaml:"after_all"`
 
 	// CI/CD 集成配置
 	CI struct {
@@ -82,8 +103,34 @@ type Scenario struct {
 	Name string `yaml:"name"`
 	// 场景描述
 	Description string `yaml:"description"`
+	// 场景标签，可用 --tag / --exclude-tag 筛选
+	Tags []string `yaml:"tags"`
+	// 场景基础URL（覆盖全局 base_url）
+	BaseURL string `yaml:"base_url"`
+	// 场景请求头（覆盖全局 request.headers）
+	Headers map[string]string `yaml:"headers"`
+	// 场景认证配置（覆盖全局 auth）
+	Auth *AuthConfig `yaml:"auth"`
+	// 数据驱动：CSV、JSON、YAML 文件路径或内联的对象数组，每一行数据运行一次场景
+	Data interface{} `yaml:"data"`
+	// 数据驱动：变量名到取值列表的映射，按所有取值的组合各运行一次场景
+	Matrix map[string][]interface{} `yaml:"matrix"`
+	// 有步骤失败时停止执行场景的剩余步骤（teardown 仍会执行）
+	StopOnFailure bool `yaml:"stop_on_failure"`
+	// 场景超时时间，如 "2m"，纯数字表示秒；超时后停止执行剩余步骤（teardown 仍会执行）
+	Timeout string `yaml:"timeout"`
+	// 自动重试次数（覆盖全局 retries），显式设置为 0 时不自动重试
+	Retries *int `yaml:"retries"`
+	// 触发自动重试的条件（覆盖全局 retry_on）
+	RetryOn *RetryOn `yaml:"retry_on"`
+	// 并行执行的最大步骤数，大于 1 时按 dependencies 构建依赖图并行执行互不依赖的步骤
+	Parallel int `yaml:"parallel"`
+	// 主体步骤之前执行的准备步骤，失败时跳过主体步骤
+	Setup []Step `yaml:"setup"`
 	// 测试步骤
 	Steps []Step `yaml:"steps"`
+	// 清理步骤，即使前面的步骤失败或运行被中断也会执行
+	Teardown []Step `yaml:"teardown"`
 }
 
 // Step 表示测试步骤
@@ -94,6 +141,12 @@ type Step struct {
 	Endpoint string `yaml:"endpoint"`
 	// HTTP方法
 	Method string `yaml:"method"`
+	// 步骤基础URL（覆盖场景和全局 base_url）
+	BaseURL string `yaml:"base_url"`
+	// 步骤请求头（覆盖场景和全局请求头）
+	Headers map[string]string `yaml:"headers"`
+	// 步骤认证配置（覆盖场景和全局 auth），type: none 表示不使用认证
+	Auth *AuthConfig `yaml:"auth"`
 	// 请求体 - 支持字符串或对象格式
 	// 注意：YAML 配置中使用 "body" 字段名，但代码中使用 RequestBody
 	RequestBody interface{} `yaml:"body"`
@@ -105,6 +158,92 @@ type Step struct {
 	Extract map[string]string `yaml:"extract"`
 	// 依赖步骤
 	Dependencies []string `yaml:"dependencies"`
+	// 执行条件：表达式为真时才执行，如 "{{.user_id}} != ''"、"steps.创建订单.status == 201"
+	If string `yaml:"if"`
+	// 跳过条件：表达式为真时跳过
+	Unless string `yaml:"unless"`
+	// 跳过条件（unless 的别名）
+	SkipIf string `yaml:"skip_if"`
 	// 断言
 	Assert map[string]interface{} `yaml:"assert"`
+	// 轮询配置：重复发送请求直到满足条件（用于异步接口）
+	Retry *PollConfig `yaml:"retry"`
+	// 请求超时时间，如 "60s"，纯数字表示秒（覆盖全局 timeout）
+	Timeout string `yaml:"timeout"`
+	// 自动重试次数（覆盖场景和全局 retries），显式设置为 0 时不自动重试
+	// 与 retry 轮询不同，只在网络错误或 retry_on 状态码时重试
+	Retries *int `yaml:"retries"`
+	// 触发自动重试的条件（覆盖场景和全局 retry_on）
+	RetryOn *RetryOn `yaml:"retry_on"`
+	// 使用的步骤模板名称，模板参数通过 with 传入
+	Use string `yaml:"use"`
+	// 调用的场景名称，被调用场景提取的变量会复制回当前场景，参数通过 with 传入
+	Call string `yaml:"call"`
+	// use / call 的参数
+	With map[string]interface{} `yaml:"with"`
+	// 循环：遍历数组变量，如 "{{.grants}}"，对每个元素运行 steps 中的嵌套步骤
+	// 嵌套步骤中可以使用 {{.item}} 和 {{.index}} 访问当前元素和下标
+	Foreach string `yaml:"foreach"`
+	// 最大循环次数，默认 100
+	MaxIterations int `yaml:"max_iterations"`
+	// 循环的嵌套步骤
+	Steps []Step `yaml:"steps"`
+}
+
+// Environment 表示一个测试环境（如 local、staging、pre-prod）的配置
+type Environment struct {
+	// 环境的基础URL
+	BaseURL string `yaml:"base_url"`
+	// 环境的请求头，与全局请求头合并，同名时覆盖
+	Headers map[string]string `yaml:"headers"`
+	// 环境的变量，与全局变量合并，同名时覆盖
+	Variables map[string]interface{} `yaml:"variables"`
+	// 环境的请求超时时间（秒）
+	Timeout int `yaml:"timeout"`
+}
+
+// AuthConfig 表示请求的认证配置，字符串字段支持模板变量
+type AuthConfig struct {
+	// 认证类型：basic、bearer、api_key、oauth2_client_credentials、oauth2_password 或 none
+	Type string `yaml:"type"`
+	// 用户名和密码（basic、oauth2_password）
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// 令牌（bearer）
+	Token string `yaml:"token"`
+	// API Key 的名称、值和位置（api_key），位置为 header（默认）、query 或 cookie
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+	In    string `yaml:"in"`
+	// 获取访问令牌的地址（oauth2_*）
+	TokenURL string `yaml:"token_url"`
+	// 客户端凭据（oauth2_*）
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// 客户端凭据的发送方式：header（默认，HTTP Basic 认证）或 body（表单字段）
+	ClientAuth string `yaml:"client_auth"`
+	// 申请的权限范围（oauth2_*）
+	Scopes []string `yaml:"scopes"`
+}
+
+// StepTemplate 表示可复用的步骤模板
+type StepTemplate struct {
+	// 参数及默认值，值为空（~）表示必填参数
+	Params map[string]interface{} `yaml:"params"`
+	// 模板步骤
+	Steps []Step `yaml:"steps"`
+}
+
+// PollConfig 表示步骤的轮询配置
+type PollConfig struct {
+	// 停止轮询的条件，格式与 assert 相同；为空时使用步骤的 assert
+	Until map[string]interface{} `yaml:"until"`
+	// 轮询间隔，如 "1s"、"500ms"，纯数字表示秒，默认 1s
+	Interval string `yaml:"interval"`
+	// 最大间隔（指数或线性退避时的上限）
+	MaxInterval string `yaml:"max_interval"`
+	// 最大尝试次数，默认 10
+	MaxAttempts int `yaml:"max_attempts"`
+	// 退避策略：constant（默认）、linear、exponential
+	Backoff string `yaml:"backoff"`
 }
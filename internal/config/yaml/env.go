@@ -0,0 +1,139 @@
+package yaml
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gaoyong06/api-tester/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// envVarPattern 匹配 ${NAME} 和 ${NAME:-default} 形式的环境变量引用
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// unmarshalConfig 解析配置文件内容：先加载 env_file，再展开所有字符串值中的环境变量引用
+// baseDir 为配置文件所在目录，用于解析 env_file 的相对路径
+func unmarshalConfig(data []byte, baseDir string, config *Config) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if len(root.Content) == 0 {
+		return nil
+	}
+
+	// env_file 需要在展开其他值之前加载
+	if envFile := mappingValue(root.Content[0], "env_file"); envFile != nil {
+		path := expandEnv(envFile.Value)
+		if path != "" {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(baseDir, path)
+			}
+			if err := loadEnvFile(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	expandNode(&root)
+
+	return root.Decode(config)
+}
+
+// mappingValue 返回映射节点中指定键的值节点，不存在时返回 nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// expandNode 递归展开节点中所有标量值的环境变量引用
+func expandNode(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode {
+		if !strings.Contains(node.Value, "${") {
+			return
+		}
+		value := expandEnv(node.Value)
+		if value != node.Value {
+			node.Value = value
+			// 未加引号的值按展开后的内容重新推断类型，如 timeout: ${TIMEOUT} 仍解析为数字
+			if node.Style == 0 {
+				node.Tag = ""
+			}
+		}
+		return
+	}
+	for _, child := range node.Content {
+		expandNode(child)
+	}
+}
+
+// expandEnv 展开字符串中的 ${NAME} 和 ${NAME:-default}
+// 变量未设置时使用默认值，没有默认值时替换为空字符串并打印警告
+// 变量名像密钥（如 API_TOKEN）时记录其值，以便在日志和报告中屏蔽
+func expandEnv(s string) string {
+	return envVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		parts := envVarPattern.FindStringSubmatch(match)
+		name := parts[1]
+
+		value, ok := os.LookupEnv(name)
+		if !ok || (value == "" && parts[2] != "") {
+			if parts[2] == "" {
+				fmt.Printf("警告: 环境变量 %s 未设置\n", name)
+			}
+			value = parts[3]
+		}
+
+		if utils.IsSecretName(name) {
+			utils.RegisterSecret(value)
+		}
+		return value
+	})
+}
+
+// loadEnvFile 加载 .env 文件，每行一个 NAME=VALUE，支持 # 注释、export 前缀和引号
+// 已经设置的环境变量不会被覆盖
+func loadEnvFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("无法读取 env_file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("env_file %s 第 %d 行格式错误，应为 NAME=VALUE", path, lineNum)
+		}
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		if _, exists := os.LookupEnv(name); !exists {
+			os.Setenv(name, value)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,56 @@
+package yaml
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ApplyEnvironment 使用指定环境的配置覆盖全局的基础URL、请求头、变量和超时时间
+// name 为空时不选择环境，只检查全局基础URL是否已设置
+func (c *Config) ApplyEnvironment(name string) error {
+	if name != "" {
+		env, exists := c.Environments[name]
+		if !exists {
+			return fmt.Errorf("未找到环境 %s，可用的环境: %s", name, strings.Join(c.environmentNames(), ", "))
+		}
+
+		if env.BaseURL != "" {
+			c.BaseURL = env.BaseURL
+		}
+		if env.Timeout != 0 {
+			c.Timeout = env.Timeout
+		}
+		if len(env.Headers) > 0 && c.Request.Headers == nil {
+			c.Request.Headers = make(map[string]string)
+		}
+		for k, v := range env.Headers {
+			c.Request.Headers[k] = v
+		}
+		if len(env.Variables) > 0 && c.Variables == nil {
+			c.Variables = make(map[string]interface{})
+		}
+		for k, v := range env.Variables {
+			c.Variables[k] = v
+		}
+	}
+
+	if c.BaseURL == "" {
+		if len(c.Environments) > 0 {
+			return fmt.Errorf("API基础URL不能为空，请使用 --env 选择环境: %s", strings.Join(c.environmentNames(), ", "))
+		}
+		return fmt.Errorf("API基础URL不能为空")
+	}
+
+	return nil
+}
+
+// environmentNames 返回按名称排序的环境列表
+func (c *Config) environmentNames() []string {
+	names := make([]string, 0, len(c.Environments))
+	for name := range c.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
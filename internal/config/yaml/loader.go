@@ -30,10 +30,13 @@ func LoadConfig(filePath string) (*Config, error) {
 
 	// 解析YAML
 	config := &Config{}
-	if err := yaml.Unmarshal(data, config); err != nil {
+	if err := unmarshalConfig(data, filepath.Dir(absPath), config); err != nil {
 		return nil, fmt.Errorf("无法解析YAML配置: %v", err)
 	}
 
+	// 场景数据文件的相对路径基于配置文件所在目录
+	resolveDataPaths(config, filepath.Dir(absPath))
+
 	// 处理包含的配置文件
 	if len(config.Includes) > 0 {
 		// 获取主配置文件所在目录，用于解析相对路径
@@ -59,6 +62,15 @@ func LoadConfig(filePath string) (*Config, error) {
 	return config, nil
 }
 
+// resolveDataPaths 将场景数据文件的相对路径解析为基于 baseDir 的路径
+func resolveDataPaths(config *Config, baseDir string) {
+	for i := range config.Scenarios {
+		if path, ok := config.Scenarios[i].Data.(string); ok && path != "" && !filepath.IsAbs(path) {
+			config.Scenarios[i].Data = filepath.Join(baseDir, path)
+		}
+	}
+}
+
 // setDefaults 设置配置的默认值
 func setDefaults(config *Config) {
 	// 设置默认超时时间
@@ -105,11 +117,16 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("API规范文件路径不能为空")
 	}
 
-	// 验证基础URL
-	if config.BaseURL == "" {
+	// 验证基础URL，配置了环境时可以由环境提供
+	if config.BaseURL == "" && len(config.Environments) == 0 {
 		return fmt.Errorf("API基础URL不能为空")
 	}
 
+	// 验证认证配置
+	if err := validateAuth(config); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -167,9 +184,10 @@ func loadAndMergeIncludes(baseConfig *Config, baseDir string) (*Config, error) {
 
 		// 解析YAML
 		includeConfig := &Config{}
-		if err := yaml.Unmarshal(data, includeConfig); err != nil {
+		if err := unmarshalConfig(data, filepath.Dir(includePath), includeConfig); err != nil {
 			return nil, fmt.Errorf("无法解析包含的YAML配置: %v", err)
 		}
+		resolveDataPaths(includeConfig, filepath.Dir(includePath))
 
 		// 如果包含的文件中也有 includes，递归处理
 		if len(includeConfig.Includes) > 0 {
@@ -236,6 +254,26 @@ func mergeConfigs(base, override *Config) *Config {
 		result.Timeout = override.Timeout
 	}
 
+	if override.Retries != 0 {
+		result.Retries = override.Retries
+	}
+
+	if override.RetryOn != nil {
+		result.RetryOn = override.RetryOn
+	}
+
+	if override.FuzzyVariables {
+		result.FuzzyVariables = true
+	}
+
+	if override.Strict {
+		result.Strict = true
+	}
+
+	if override.Auth != nil {
+		result.Auth = override.Auth
+	}
+
 	// 合并 Request 结构
 	// 合并 Headers
 	for k, v := range override.Request.Headers {
@@ -272,6 +310,26 @@ func mergeConfigs(base, override *Config) *Config {
 	// 合并 Scenarios
 	result.Scenarios = append(result.Scenarios, override.Scenarios...)
 
+	// 合并环境配置，同名环境使用覆盖配置中的定义
+	if len(override.Environments) > 0 && result.Environments == nil {
+		result.Environments = make(map[string]Environment)
+	}
+	for name, env := range override.Environments {
+		result.Environments[name] = env
+	}
+
+	// 合并步骤模板，同名模板使用覆盖配置中的定义
+	if len(override.StepTemplates) > 0 && result.StepTemplates == nil {
+		result.StepTemplates = make(map[string]StepTemplate)
+	}
+	for name, template := range override.StepTemplates {
+		result.StepTemplates[name] = template
+	}
+
+	// 合并 before_all / after_all
+	result.BeforeAll = append(result.BeforeAll, override.BeforeAll...)
+	result.AfterAll = append(result.AfterAll, override.AfterAll...)
+
 	// 合并 CI 配置
 	if override.CI.OutputFormat != "" {
 		result.CI.OutputFormat = override.CI.OutputFormat
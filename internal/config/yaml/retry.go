@@ -0,0 +1,40 @@
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RetryOnNetworkError retry_on 中表示网络错误的关键字
+const RetryOnNetworkError = "network_error"
+
+// RetryOn 触发自动重试的条件，配置为状态码和关键字组成的数组，如 [502, 503, network_error]
+type RetryOn struct {
+	// 触发重试的状态码
+	StatusCodes []int
+	// 非幂等请求（如 POST）遇到网络错误时是否也重试；幂等请求总是重试网络错误
+	NetworkErrors bool
+}
+
+// UnmarshalYAML 解析 retry_on 数组，元素可以是状态码或 network_error
+func (r *RetryOn) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("第 %d 行: retry_on 必须是数组", node.Line)
+	}
+
+	*r = RetryOn{}
+	for _, item := range node.Content {
+		if item.Kind == yaml.ScalarNode && item.Value == RetryOnNetworkError {
+			r.NetworkErrors = true
+			continue
+		}
+		status, err := strconv.Atoi(item.Value)
+		if item.Kind != yaml.ScalarNode || err != nil {
+			return fmt.Errorf("第 %d 行: retry_on 只能包含状态码或 %s，实际为 %s", item.Line, RetryOnNetworkError, item.Value)
+		}
+		r.StatusCodes = append(r.StatusCodes, status)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRetryOnUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *RetryOn
+		wantErr string
+	}{
+		{"status codes", "retry_on: [502, 503]", &RetryOn{StatusCodes: []int{502, 503}}, ""},
+		{"network error keyword", "retry_on: [503, network_error]", &RetryOn{StatusCodes: []int{503}, NetworkErrors: true}, ""},
+		{"only network errors", "retry_on: [network_error]", &RetryOn{NetworkErrors: true}, ""},
+		{"empty list", "retry_on: []", &RetryOn{}, ""},
+		{"not configured", "retries: 1", nil, ""},
+		{"unknown keyword", "retry_on: [timeout]", nil, "retry_on 只能包含状态码或 network_error"},
+		{"not a list", "retry_on: 503", nil, "retry_on 必须是数组"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var step Step
+			err := yaml.Unmarshal([]byte(tt.input), &step)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Unmarshal() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(step.RetryOn, tt.want) {
+				t.Errorf("RetryOn = %+v, want %+v", step.RetryOn, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// missing 表示过滤器中 @ 路径没有匹配到值
+type missing struct{}
+
+// filterExpr 过滤器表达式，对数组元素（或对象的值）求值
+type filterExpr interface {
+	eval(node interface{}) interface{}
+}
+
+// pathOperand 相对路径 @.name
+type pathOperand struct {
+	segments []segment
+}
+
+func (p pathOperand) eval(node interface{}) interface{} {
+	matches := evaluate(p.segments, node)
+	if len(matches) == 0 {
+		return missing{}
+	}
+	return matches[0]
+}
+
+// literalOperand 字符串、数字、true、false 或 null
+type literalOperand struct {
+	value interface{}
+}
+
+func (l literalOperand) eval(interface{}) interface{} {
+	return l.value
+}
+
+// notExpr 逻辑非
+type notExpr struct {
+	expr filterExpr
+}
+
+func (n notExpr) eval(node interface{}) interface{} {
+	return !truthy(n.expr.eval(node))
+}
+
+// binaryExpr 比较或逻辑运算
+type binaryExpr struct {
+	op          string
+	left, right filterExpr
+	// =~ 的正则表达式
+	pattern *regexp.Regexp
+}
+
+func (b binaryExpr) eval(node interface{}) interface{} {
+	switch b.op {
+	case "&&":
+		return truthy(b.left.eval(node)) && truthy(b.right.eval(node))
+	case "||":
+		return truthy(b.left.eval(node)) || truthy(b.right.eval(node))
+	case "=~":
+		text, ok := b.left.eval(node).(string)
+		return ok && b.pattern.MatchString(text)
+	default:
+		return compare(b.left.eval(node), b.op, b.right.eval(node))
+	}
+}
+
+// truthy 判断过滤器的结果是否为真：单独的 @ 路径表示存在性检查
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case missing:
+		return false
+	case bool:
+		return v
+	default:
+		return true
+	}
+}
+
+// compare 比较两个值：都是数字时按数字比较，都是字符串时按字符串比较，其他类型只支持 == 和 !=
+// 任意一边不存在时比较结果为假
+func compare(left interface{}, op string, right interface{}) bool {
+	if _, ok := left.(missing); ok {
+		return false
+	}
+	if _, ok := right.(missing); ok {
+		return false
+	}
+
+	if l, ok := toNumber(left); ok {
+		if r, ok := toNumber(right); ok {
+			switch op {
+			case "==":
+				return l == r
+			case "!=":
+				return l != r
+			case "<":
+				return l < r
+			case "<=":
+				return l <= r
+			case ">":
+				return l > r
+			case ">=":
+				return l >= r
+			}
+		}
+	}
+
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			switch op {
+			case "==":
+				return l == r
+			case "!=":
+				return l != r
+			case "<":
+				return l < r
+			case "<=":
+				return l <= r
+			case ">":
+				return l > r
+			case ">=":
+				return l >= r
+			}
+		}
+	}
+
+	switch op {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	}
+	return false
+}
+
+// toNumber 将 JSON 数字转换为 float64
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// filterParser 过滤器表达式解析器
+type filterParser struct {
+	input string
+	pos   int
+}
+
+// parseFilter 解析过滤器表达式，如 @.price < 10 && @.category == 'book'
+func parseFilter(input string) (filterExpr, error) {
+	p := &filterParser{input: input}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("位置 %d 处有多余的内容 %q", p.pos+1, p.input[p.pos:])
+	}
+	return expr, nil
+}
+
+// parseOr 解析 ||
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd 解析 &&
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseComparison 解析比较运算，没有比较运算符时返回操作数本身
+func (p *filterParser) parseComparison() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.consume("=~") {
+		pattern, err := p.parseRegex()
+		if err != nil {
+			return nil, err
+		}
+		return binaryExpr{op: "=~", left: left, pattern: pattern}, nil
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return binaryExpr{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+// parseUnary 解析 !、括号和操作数
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("表达式不完整")
+	}
+
+	switch c := p.input[p.pos]; {
+	case c == '!' && !strings.HasPrefix(p.input[p.pos:], "!="):
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr: expr}, nil
+	case c == '(':
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, fmt.Errorf("缺少右括号")
+		}
+		return expr, nil
+	case c == '@':
+		return p.parsePath()
+	case c == '\'' || c == '"':
+		return p.parseString()
+	default:
+		return p.parseLiteral()
+	}
+}
+
+// parsePath 解析 @ 开头的相对路径
+func (p *filterParser) parsePath() (filterExpr, error) {
+	start := p.pos + 1
+	end := start
+	for end < len(p.input) {
+		c := p.input[end]
+		if c == '[' {
+			closing := closingBracket(p.input, end)
+			if closing < 0 {
+				return nil, fmt.Errorf("缺少 ]")
+			}
+			end = closing + 1
+			continue
+		}
+		if c == '.' || c == '_' || c == '-' || c == '*' || isAlphanumeric(c) {
+			end++
+			continue
+		}
+		break
+	}
+
+	segments, err := parseSegments(p.input[start:end])
+	if err != nil {
+		return nil, err
+	}
+	p.pos = end
+	return pathOperand{segments: segments}, nil
+}
+
+// parseString 解析单引号或双引号字符串
+func (p *filterParser) parseString() (filterExpr, error) {
+	quote := p.input[p.pos]
+	for end := p.pos + 1; end < len(p.input); end++ {
+		if p.input[end] == '\\' {
+			end++
+			continue
+		}
+		if p.input[end] == quote {
+			value, err := unquote(p.input[p.pos : end+1])
+			if err != nil {
+				return nil, err
+			}
+			p.pos = end + 1
+			return literalOperand{value: value}, nil
+		}
+	}
+	return nil, fmt.Errorf("字符串缺少结束引号")
+}
+
+// parseLiteral 解析数字、true、false 和 null
+func (p *filterParser) parseLiteral() (filterExpr, error) {
+	end := p.pos
+	for end < len(p.input) && (isAlphanumeric(p.input[end]) || p.input[end] == '.' || p.input[end] == '-' || p.input[end] == '+') {
+		end++
+	}
+	word := p.input[p.pos:end]
+	if word == "" {
+		return nil, fmt.Errorf("位置 %d 处缺少操作数", p.pos+1)
+	}
+
+	var value interface{}
+	switch word {
+	case "true":
+		value = true
+	case "false":
+		value = false
+	case "null":
+		value = nil
+	default:
+		number, err := strconv.ParseFloat(word, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的操作数 %q", word)
+		}
+		value = number
+	}
+	p.pos = end
+	return literalOperand{value: value}, nil
+}
+
+// parseRegex 解析 /pattern/ 或 /pattern/i 形式的正则表达式
+func (p *filterParser) parseRegex() (*regexp.Regexp, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) || p.input[p.pos] != '/' {
+		return nil, fmt.Errorf("=~ 后应为 /正则表达式/")
+	}
+	for end := p.pos + 1; end < len(p.input); end++ {
+		if p.input[end] == '\\' {
+			end++
+			continue
+		}
+		if p.input[end] == '/' {
+			pattern := p.input[p.pos+1 : end]
+			p.pos = end + 1
+			if p.pos < len(p.input) && p.input[p.pos] == 'i' {
+				pattern = "(?i)" + pattern
+				p.pos++
+			}
+			return regexp.Compile(pattern)
+		}
+	}
+	return nil, fmt.Errorf("正则表达式缺少结束的 /")
+}
+
+// consume 跳过空白后，如果接下来是 token 则消费它
+func (p *filterParser) consume(token string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.input[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// skipSpaces 跳过空白字符
+func (p *filterParser) skipSpaces() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// isAlphanumeric 判断是否为 ASCII 字母或数字
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,345 @@
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// segmentKind 路径段的类型
+type segmentKind int
+
+const (
+	// 子成员，如 .name、['name']
+	segmentChild segmentKind = iota
+	// 通配符，如 .*、[*]
+	segmentWildcard
+	// 数组下标，如 [0]、[-1]
+	segmentIndex
+	// 数组切片，如 [1:3]
+	segmentSlice
+	// 过滤器，如 [?(@.price < 10)]
+	segmentFilter
+)
+
+// segment 路径中的一段
+type segment struct {
+	kind segmentKind
+	// 是否递归查找（..name）
+	recursive bool
+	// 子成员名称
+	name string
+	// 数组下标，切片时为起始下标
+	index int
+	// 切片的结束下标（不含）
+	end int
+	// 切片是否指定了起始和结束下标
+	hasStart, hasEnd bool
+	// 过滤条件
+	filter filterExpr
+}
+
+// Path 解析后的 JSONPath 表达式
+type Path struct {
+	source   string
+	segments []segment
+}
+
+// Parse 解析 JSONPath 表达式，如 $.items[?(@.name == 'x')].id
+// 支持子成员（.name、['name']）、下标（[0]、[-1]）、切片（[1:3]）、通配符（*）、
+// 递归查找（..name）和过滤器（[?(...)]，支持 ==、!=、<、<=、>、>=、=~ /正则/、&&、|| 和 !）
+func Parse(path string) (*Path, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSONPath 必须以 $ 开头: %s", path)
+	}
+
+	segments, err := parseSegments(path[1:])
+	if err != nil {
+		return nil, fmt.Errorf("无效的 JSONPath %s: %v", path, err)
+	}
+	return &Path{source: path, segments: segments}, nil
+}
+
+// String 返回原始表达式
+func (p *Path) String() string {
+	return p.source
+}
+
+// Definite 判断路径是否最多只匹配一个值（不含通配符、切片、过滤器和递归查找）
+func (p *Path) Definite() bool {
+	for _, seg := range p.segments {
+		if seg.recursive || (seg.kind != segmentChild && seg.kind != segmentIndex) {
+			return false
+		}
+	}
+	return true
+}
+
+// Query 在 data（encoding/json 解析得到的值）上执行查询，按文档顺序返回所有匹配的值
+func (p *Path) Query(data interface{}) []interface{} {
+	return evaluate(p.segments, data)
+}
+
+// Query 解析并执行 JSONPath 表达式
+func Query(data interface{}, path string) ([]interface{}, error) {
+	parsed, err := Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Query(data), nil
+}
+
+// evaluate 依次应用路径段
+func evaluate(segments []segment, data interface{}) []interface{} {
+	nodes := []interface{}{data}
+	for _, seg := range segments {
+		var next []interface{}
+		for _, node := range nodes {
+			if seg.recursive {
+				for _, descendant := range descendants(node) {
+					next = append(next, seg.apply(descendant)...)
+				}
+			} else {
+				next = append(next, seg.apply(node)...)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// apply 在单个节点上应用路径段
+func (seg segment) apply(node interface{}) []interface{} {
+	switch seg.kind {
+	case segmentChild:
+		if object, ok := node.(map[string]interface{}); ok {
+			if value, exists := object[seg.name]; exists {
+				return []interface{}{value}
+			}
+		}
+	case segmentWildcard:
+		return children(node)
+	case segmentIndex:
+		if array, ok := node.([]interface{}); ok {
+			index := seg.index
+			if index < 0 {
+				index += len(array)
+			}
+			if index >= 0 && index < len(array) {
+				return []interface{}{array[index]}
+			}
+		}
+	case segmentSlice:
+		if array, ok := node.([]interface{}); ok {
+			start, end := 0, len(array)
+			if seg.hasStart {
+				start = clampIndex(seg.index, len(array))
+			}
+			if seg.hasEnd {
+				end = clampIndex(seg.end, len(array))
+			}
+			if start < end {
+				return append([]interface{}{}, array[start:end]...)
+			}
+		}
+	case segmentFilter:
+		var matched []interface{}
+		for _, child := range children(node) {
+			if truthy(seg.filter.eval(child)) {
+				matched = append(matched, child)
+			}
+		}
+		return matched
+	}
+	return nil
+}
+
+// clampIndex 将可能为负数的切片下标转换为 [0, length] 范围内的下标
+func clampIndex(index, length int) int {
+	if index < 0 {
+		index += length
+	}
+	if index < 0 {
+		return 0
+	}
+	if index > length {
+		return length
+	}
+	return index
+}
+
+// children 返回对象的所有值（按键排序）或数组的所有元素
+func children(node interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]interface{}, 0, len(v))
+		for _, key := range keys {
+			values = append(values, v[key])
+		}
+		return values
+	case []interface{}:
+		return v
+	default:
+		return nil
+	}
+}
+
+// descendants 返回节点本身及其所有后代节点
+func descendants(node interface{}) []interface{} {
+	result := []interface{}{node}
+	for _, child := range children(node) {
+		result = append(result, descendants(child)...)
+	}
+	return result
+}
+
+// parseSegments 解析 $ 之后的路径段
+func parseSegments(path string) ([]segment, error) {
+	var segments []segment
+	for pos := 0; pos < len(path); {
+		recursive := false
+		switch {
+		case strings.HasPrefix(path[pos:], ".."):
+			recursive = true
+			pos += 2
+		case path[pos] == '.':
+			pos++
+		case path[pos] == '[':
+		default:
+			return nil, fmt.Errorf("位置 %d 处的字符 %q 无效", pos+1, path[pos])
+		}
+
+		var seg segment
+		if pos < len(path) && path[pos] == '[' {
+			end := closingBracket(path, pos)
+			if end < 0 {
+				return nil, fmt.Errorf("缺少 ]")
+			}
+			var err error
+			seg, err = parseBracket(path[pos+1 : end])
+			if err != nil {
+				return nil, err
+			}
+			pos = end + 1
+		} else {
+			end := pos
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			name := path[pos:end]
+			switch name {
+			case "":
+				return nil, fmt.Errorf("位置 %d 处缺少成员名称", pos+1)
+			case "*":
+				seg = segment{kind: segmentWildcard}
+			default:
+				seg = segment{kind: segmentChild, name: name}
+			}
+			pos = end
+		}
+
+		seg.recursive = recursive
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// closingBracket 返回与 start 处的 [ 匹配的 ] 的位置，忽略引号中的字符
+func closingBracket(path string, start int) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseBracket 解析方括号中的内容：'name'、*、下标、切片或过滤器
+func parseBracket(content string) (segment, error) {
+	content = strings.TrimSpace(content)
+	switch {
+	case content == "*":
+		return segment{kind: segmentWildcard}, nil
+	case strings.HasPrefix(content, "?"):
+		expr := strings.TrimSpace(content[1:])
+		if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+			expr = expr[1 : len(expr)-1]
+		}
+		filter, err := parseFilter(expr)
+		if err != nil {
+			return segment{}, fmt.Errorf("无效的过滤器 %s: %v", content, err)
+		}
+		return segment{kind: segmentFilter, filter: filter}, nil
+	case strings.HasPrefix(content, "'") || strings.HasPrefix(content, "\""):
+		name, err := unquote(content)
+		if err != nil {
+			return segment{}, err
+		}
+		return segment{kind: segmentChild, name: name}, nil
+	case strings.Contains(content, ":"):
+		parts := strings.Split(content, ":")
+		if len(parts) != 2 {
+			return segment{}, fmt.Errorf("不支持的切片 [%s]", content)
+		}
+		seg := segment{kind: segmentSlice}
+		var err error
+		if start := strings.TrimSpace(parts[0]); start != "" {
+			if seg.index, err = strconv.Atoi(start); err != nil {
+				return segment{}, fmt.Errorf("无效的切片 [%s]", content)
+			}
+			seg.hasStart = true
+		}
+		if end := strings.TrimSpace(parts[1]); end != "" {
+			if seg.end, err = strconv.Atoi(end); err != nil {
+				return segment{}, fmt.Errorf("无效的切片 [%s]", content)
+			}
+			seg.hasEnd = true
+		}
+		return seg, nil
+	default:
+		index, err := strconv.Atoi(content)
+		if err != nil {
+			return segment{}, fmt.Errorf("无效的下标 [%s]", content)
+		}
+		return segment{kind: segmentIndex, index: index}, nil
+	}
+}
+
+// unquote 去除单引号或双引号，处理反斜杠转义
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return "", fmt.Errorf("引号不匹配: %s", s)
+	}
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '\\' && i+1 < len(s)-1 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,214 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDocument = `{
+	"store": {
+		"book": [
+			{"title": "Sayings", "category": "reference", "price": 8.95},
+			{"title": "Sword", "category": "fiction", "price": 12.99, "isbn": "0-553"},
+			{"title": "Moby Dick", "category": "fiction", "price": 8.99, "isbn": "0-395"},
+			{"title": "The Lord", "category": "fiction", "price": 22.99, "tags": ["epic"]}
+		],
+		"bicycle": {"color": "red", "price": 19.95}
+	},
+	"odd keys": {"a.b": 1, "it's": 2, "say \"hi\"": 3, "back\\slash": 4},
+	"empty": [],
+	"flag": false
+}`
+
+func TestQuery(t *testing.T) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(testDocument), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want []interface{}
+	}{
+		// 根节点和子成员
+		{"root", "$", []interface{}{data}},
+		{"child", "$.store.bicycle.color", []interface{}{"red"}},
+		{"bracket child", "$['store']['bicycle']['color']", []interface{}{"red"}},
+		{"double quoted child", `$["store"]["bicycle"].price`, []interface{}{19.95}},
+		{"missing child", "$.store.car", nil},
+		{"child of array", "$.store.book.title", nil},
+		{"false value", "$.flag", []interface{}{false}},
+		{"surrounding spaces", "  $.store.bicycle.color  ", []interface{}{"red"}},
+
+		// 带引号的成员名称和转义
+		{"name with dot", "$['odd keys']['a.b']", []interface{}{1.0}},
+		{"escaped single quote", `$['odd keys']['it\'s']`, []interface{}{2.0}},
+		{"double quotes inside single quotes", `$['odd keys']['say "hi"']`, []interface{}{3.0}},
+		{"escaped double quote", `$["odd keys"]["say \"hi\""]`, []interface{}{3.0}},
+		{"escaped backslash", `$['odd keys']['back\\slash']`, []interface{}{4.0}},
+		{"bracket inside quotes", `$['odd keys']['a]b']`, nil},
+
+		// 下标
+		{"index", "$.store.book[0].title", []interface{}{"Sayings"}},
+		{"negative index", "$.store.book[-1].title", []interface{}{"The Lord"}},
+		{"negative index from end", "$.store.book[-4].title", []interface{}{"Sayings"}},
+		{"index out of range", "$.store.book[4]", nil},
+		{"negative index out of range", "$.store.book[-5]", nil},
+		{"index on object", "$.store.bicycle[0]", nil},
+		{"index on empty array", "$.empty[0]", nil},
+
+		// 切片
+		{"slice", "$.store.book[1:3].title", []interface{}{"Sword", "Moby Dick"}},
+		{"slice without start", "$.store.book[:2].title", []interface{}{"Sayings", "Sword"}},
+		{"slice without end", "$.store.book[2:].title", []interface{}{"Moby Dick", "The Lord"}},
+		{"slice with negative start", "$.store.book[-2:].title", []interface{}{"Moby Dick", "The Lord"}},
+		{"slice with negative end", "$.store.book[:-3].title", []interface{}{"Sayings"}},
+		{"slice beyond length", "$.store.book[3:10].title", []interface{}{"The Lord"}},
+		{"empty slice", "$.store.book[3:1]", nil},
+		{"full slice", "$.store.book[:].price", []interface{}{8.95, 12.99, 8.99, 22.99}},
+
+		// 通配符
+		{"wildcard on array", "$.store.book[*].category", []interface{}{"reference", "fiction", "fiction", "fiction"}},
+		{"dot wildcard on array", "$.store.book.*.title", []interface{}{"Sayings", "Sword", "Moby Dick", "The Lord"}},
+		{"wildcard on object sorted by key", "$.store.bicycle.*", []interface{}{"red", 19.95}},
+
+		// 递归查找
+		{"recursive child", "$..isbn", []interface{}{"0-553", "0-395"}},
+		{"recursive price in document order", "$.store..price", []interface{}{19.95, 8.95, 12.99, 8.99, 22.99}},
+		{"recursive index", "$..tags[0]", []interface{}{"epic"}},
+		{"recursive bracket child", "$..['color']", []interface{}{"red"}},
+		{"recursive missing", "$..author", nil},
+
+		// 过滤器
+		{"filter comparison", "$.store.book[?(@.price < 10)].title", []interface{}{"Sayings", "Moby Dick"}},
+		{"filter without parentheses", "$.store.book[?@.price >= 22.99].title", []interface{}{"The Lord"}},
+		{"filter string equality", "$.store.book[?(@.category == 'reference')].title", []interface{}{"Sayings"}},
+		{"filter double quoted string", `$.store.book[?(@.category != "fiction")].title`, []interface{}{"Sayings"}},
+		{"filter existence", "$.store.book[?(@.isbn)].title", []interface{}{"Sword", "Moby Dick"}},
+		{"filter regex", "$.store.book[?(@.title =~ /^S/)].title", []interface{}{"Sayings", "Sword"}},
+		{"filter case-insensitive regex", "$.store.book[?(@.title =~ /dick$/i)].title", []interface{}{"Moby Dick"}},
+		{"filter regex with escaped slash", `$.store.book[?(@.isbn =~ /0\/?-553/)].title`, []interface{}{"Sword"}},
+		{"filter regex on number", "$.store.book[?(@.price =~ /8/)].title", nil},
+		{"filter and", "$.store.book[?(@.category == 'fiction' && @.price < 20)].title", []interface{}{"Sword", "Moby Dick"}},
+		{"filter or", "$.store.book[?(@.price < 9 || @.tags)].title", []interface{}{"Sayings", "Moby Dick", "The Lord"}},
+		{"filter and binds tighter than or", "$.store.book[?(@.price > 20 || @.isbn && @.price < 10)].title", []interface{}{"Moby Dick", "The Lord"}},
+		{"filter not", "$.store.book[?(!@.isbn)].title", []interface{}{"Sayings", "The Lord"}},
+		{"filter not with parentheses", "$.store.book[?(!(@.category == 'fiction' || @.price > 20))].title", []interface{}{"Sayings"}},
+		{"filter not equal is not negation", "$.store.book[?(@.price != 8.95)].title", []interface{}{"Sword", "Moby Dick", "The Lord"}},
+		{"filter missing value never compares", "$.store.book[?(@.isbn != '0-553')].title", []interface{}{"Moby Dick"}},
+		{"filter nested path", "$.store.book[?(@.tags[0] == 'epic')].title", []interface{}{"The Lord"}},
+		{"filter on object values", "$.store[?(@.color == 'red')].price", []interface{}{19.95}},
+		{"filter literal true", "$.store.book[?(true)].price", []interface{}{8.95, 12.99, 8.99, 22.99}},
+		{"filter null", "$.store.book[?(@.isbn == null)]", nil},
+		{"recursive filter", "$..[?(@.price > 19)].price", []interface{}{19.95, 22.99}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Query(data, tt.path)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.path, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"store.book", "JSONPath 必须以 $ 开头"},
+		{"", "JSONPath 必须以 $ 开头"},
+		{"$store", "位置 1 处的字符 's' 无效"},
+		{"$.store.", "缺少成员名称"},
+		{"$.store..", "缺少成员名称"},
+		{"$.store[0", "缺少 ]"},
+		{"$['store]", "缺少 ]"},
+		{"$['store'x]", "引号不匹配"},
+		{"$[1:2:3]", "不支持的切片"},
+		{"$[a:2]", "无效的切片"},
+		{"$[1:b]", "无效的切片"},
+		{"$[abc]", "无效的下标"},
+		{"$[]", "无效的下标"},
+		{"$[?(@.price <)]", "表达式不完整"},
+		{"$[?(@.price < 10 &&)]", "表达式不完整"},
+		{"$[?(@.price < 10 @.x)]", "多余的内容"},
+		{"$[?((@.price < 10)]", "缺少右括号"},
+		{"$[?(@.tags[0 == 1)]", "缺少 ]"},
+		{"$[?(@.price < )]", "表达式不完整"},
+		{"$[?(@.price < ~)]", "缺少操作数"},
+		{"$[?(@.price < abc)]", "无效的操作数"},
+		{"$[?(@.name =~ abc)]", "=~ 后应为 /正则表达式/"},
+		{"$[?(@.name =~ /abc)]", "正则表达式缺少结束的 /"},
+		{"$[?(@.name =~ /(abc/)]", "missing closing )"},
+		{"$[?(@..)]", "缺少成员名称"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := Parse(tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	// 路径中的引号由 closingBracket 配对，未闭合的字符串只能在直接解析过滤器时出现
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"@.name == 'abc", "字符串缺少结束引号"},
+		{`@.name == "abc\"`, "字符串缺少结束引号"},
+		{"", "表达式不完整"},
+		{"!", "表达式不完整"},
+		{"@.price > 10 ||", "表达式不完整"},
+		{"@.price > 10)", "多余的内容"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := parseFilter(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseFilter(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefinite(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"$", true},
+		{"$.store.book[0].title", true},
+		{"$['store']['book'][-1]", true},
+		{"$.store.book[*].title", false},
+		{"$.store.*", false},
+		{"$.store.book[0:2]", false},
+		{"$..title", false},
+		{"$.store.book[?(@.price < 10)]", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := Parse(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := path.Definite(); got != tt.want {
+				t.Errorf("Definite() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
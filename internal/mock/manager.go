@@ -1,6 +1,8 @@
 package mock
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -73,6 +75,51 @@ func (m *TestDataManager) loadDataSource(source configyaml.DataSource) (map[stri
 
 // loadFromFile 从文件加载数据
 func (m *TestDataManager) loadFromFile(path string) (map[string]interface{}, error) {
+	data, err := readDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("数据文件 %s 的顶层必须是对象", path)
+	}
+
+	return result, nil
+}
+
+// LoadRows 从 CSV、JSON 或 YAML 文件加载数据行，用于数据驱动的测试场景
+// CSV 文件的第一行为列名；JSON 和 YAML 文件的顶层必须是对象数组
+func LoadRows(path string) ([]map[string]interface{}, error) {
+	data, err := readDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ToRows(data)
+}
+
+// ToRows 将解析出的数据转换为数据行，每一行必须是对象
+func ToRows(data interface{}) ([]map[string]interface{}, error) {
+	items, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("数据必须是数组，实际为 %T", data)
+	}
+
+	rows := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("第 %d 行数据必须是对象，实际为 %T", i+1, item)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readDataFile 读取数据文件，根据文件扩展名解析为对象或数组
+func readDataFile(path string) (interface{}, error) {
 	// 获取文件绝对路径
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -92,7 +139,7 @@ func (m *TestDataManager) loadFromFile(path string) (map[string]interface{}, err
 
 	// 根据文件扩展名解析数据
 	ext := filepath.Ext(absPath)
-	var result map[string]interface{}
+	var result interface{}
 
 	switch strings.ToLower(ext) {
 	case ".json":
@@ -103,6 +150,8 @@ func (m *TestDataManager) loadFromFile(path string) (map[string]interface{}, err
 		if err := yaml.Unmarshal(data, &result); err != nil {
 			return nil, fmt.Errorf("无法解析 YAML 文件: %v", err)
 		}
+	case ".csv":
+		return parseCSV(data)
 	default:
 		return nil, fmt.Errorf("不支持的文件格式: %s", ext)
 	}
@@ -110,6 +159,35 @@ func (m *TestDataManager) loadFromFile(path string) (map[string]interface{}, err
 	return result, nil
 }
 
+// parseCSV 解析 CSV 数据，第一行为列名，返回对象数组
+func parseCSV(data []byte) (interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("无法解析 CSV 文件: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV 文件为空")
+	}
+
+	header := records[0]
+	rows := make([]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			column = strings.TrimSpace(column)
+			if column == "" || i >= len(record) {
+				continue
+			}
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
 // loadFromSQL 从 SQL 数据库加载数据
 func (m *TestDataManager) loadFromSQL(connectionString string) (map[string]interface{}, error) {
 	// 这里简化实现，实际项目中应该使用数据库驱动
@@ -37,7 +37,13 @@ type MachineReport struct {
 		Passed int `json:"passed" xml:"passed"`
 		// 失败测试数
 		Failed int `json:"failed" xml:"failed"`
-		// 通过率
+		// 跳过测试数
+		Skipped int `json:"skipped" xml:"skipped"`
+		// 不稳定（自动重试后才通过）的测试数，包含在通过数中
+		Flaky int `json:"flaky" xml:"flaky"`
+		// 不稳定的测试，格式为 "场景 / 步骤" 或 "方法 路径"
+		FlakyTests []string `json:"flaky_tests,omitempty" xml:"flaky_tests>test,omitempty"`
+		// 通过率（不含跳过的测试）
 		PassRate float64 `json:"pass_rate" xml:"pass_rate"`
 		// 总响应时间
 		TotalResponseTime int64 `json:"total_response_time" xml:"total_response_time"`
@@ -81,6 +87,11 @@ type MachineReport struct {
 
 // TestResult 表示单个测试结果
 type TestResult struct {
+	// 场景名称
+	Scenario string `json:"scenario,omitempty" xml:"scenario,omitempty"`
+	// 步骤名称
+	Step string `json:"step,omitempty" xml:"step,omitempty"`
+
 	// 端点信息
 	Endpoint struct {
 		Path        string   `json:"path" xml:"path"`
@@ -92,27 +103,53 @@ type TestResult struct {
 
 	// 验证结果
 	Validation struct {
-		Passed         bool   `json:"passed" xml:"passed"`
-		FailureReason  string `json:"failure_reason,omitempty" xml:"failure_reason,omitempty"`
-		ExpectedStatus string `json:"expected_status,omitempty" xml:"expected_status,omitempty"`
-		ActualStatus   int    `json:"actual_status" xml:"actual_status"`
-		ResponseTime   int64  `json:"response_time" xml:"response_time"`
-		ResponseBody   string `json:"response_body,omitempty" xml:"response_body,omitempty"`
+		Passed         bool        `json:"passed" xml:"passed"`
+		Skipped        bool        `json:"skipped,omitempty" xml:"skipped,omitempty"`
+		Flaky          bool        `json:"flaky,omitempty" xml:"flaky,omitempty"`
+		Retries        int         `json:"retries,omitempty" xml:"retries,omitempty"`
+		SkipReason     string      `json:"skip_reason,omitempty" xml:"skip_reason,omitempty"`
+		FailureReason  string      `json:"failure_reason,omitempty" xml:"failure_reason,omitempty"`
+		ExpectedStatus string      `json:"expected_status,omitempty" xml:"expected_status,omitempty"`
+		ActualStatus   int         `json:"actual_status" xml:"actual_status"`
+		ResponseTime   int64       `json:"response_time" xml:"response_time"`
+		ResponseBody   string      `json:"response_body,omitempty" xml:"response_body,omitempty"`
+		Assertions     []Assertion `json:"assertions,omitempty" xml:"assertions>assertion,omitempty"`
+		Attempts       []Attempt   `json:"attempts,omitempty" xml:"attempts>attempt,omitempty"`
 	} `json:"validation" xml:"validation"`
 
 	// 测试时间
 	Timestamp string `json:"timestamp" xml:"timestamp"`
 }
 
-// GenerateReport 生成机器可读的测试报告
-func GenerateReport(apiDef *parser.APIDefinition, results []*types.EndpointTestResult, outputDir string, format string) (string, error) {
+// Assertion 表示单条断言的结果
+type Assertion struct {
+	Path     string `json:"path" xml:"path"`
+	Operator string `json:"operator" xml:"operator"`
+	Expected string `json:"expected" xml:"expected"`
+	Actual   string `json:"actual" xml:"actual"`
+	Passed   bool   `json:"passed" xml:"passed"`
+	Message  string `json:"message,omitempty" xml:"message,omitempty"`
+}
+
+// Attempt 表示一次请求尝试的结果
+type Attempt struct {
+	Attempt       int    `json:"attempt" xml:"attempt"`
+	StatusCode    int    `json:"status_code" xml:"status_code"`
+	ResponseTime  int64  `json:"response_time" xml:"response_time"`
+	Passed        bool   `json:"passed" xml:"passed"`
+	FailureReason string `json:"failure_reason,omitempty" xml:"failure_reason,omitempty"`
+	Timestamp     string `json:"timestamp" xml:"timestamp"`
+}
+
+// GenerateReport 生成机器可读的测试报告，environment 为测试环境名称，为空时记为 "default"
+func GenerateReport(apiDef *parser.APIDefinition, results []*types.EndpointTestResult, outputDir string, format string, environment string) (string, error) {
 	// 创建输出目录
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return "", fmt.Errorf("无法创建输出目录: %v", err)
 	}
 
 	// 准备报告数据
-	report := prepareMachineReport(apiDef, results)
+	report := prepareMachineReport(apiDef, results, environment)
 
 	// 生成报告文件名
 	extension := ".json"
@@ -130,7 +167,7 @@ func GenerateReport(apiDef *parser.APIDefinition, results []*types.EndpointTestR
 	var err error
 	
 	// 确保报告数据完整
-	report = prepareMachineReport(apiDef, results)
+	report = prepareMachineReport(apiDef, results, environment)
 	
 	switch format {
 	case "xml":
@@ -168,7 +205,7 @@ func GenerateReport(apiDef *parser.APIDefinition, results []*types.EndpointTestR
 }
 
 // prepareMachineReport 准备机器可读的报告数据
-func prepareMachineReport(apiDef *parser.APIDefinition, results []*types.EndpointTestResult) *MachineReport {
+func prepareMachineReport(apiDef *parser.APIDefinition, results []*types.EndpointTestResult, environment string) *MachineReport {
 	// 输出调试信息
 	fmt.Printf("准备机器可读报告，测试结果数量: %d\n", len(results))
 
@@ -179,48 +216,49 @@ func prepareMachineReport(apiDef *parser.APIDefinition, results []*types.Endpoin
 	report.Metadata.Title = apiDef.Title
 	report.Metadata.Version = apiDef.Version
 	report.Metadata.Timestamp = time.Now().Format(time.RFC3339)
-	report.Metadata.Environment = "development" // 可以从配置中获取
+	report.Metadata.Environment = environmentName(environment)
 
 	// 计算统计数据
 	total := len(results)
 	passed := 0
 	failed := 0
+	skipped := 0
 	totalResponseTime := int64(0)
 	minResponseTime := int64(0)
 	maxResponseTime := int64(0)
 	statusCodeDistribution := make(map[string]int)
 	errorDistribution := make(map[string]int)
 
-	if total > 0 {
-		minResponseTime = results[0].Validation.ResponseTime
-	}
-
 	// 处理测试结果
 	report.Results = make([]TestResult, 0, total)
-	for i, result := range results {
-		// 更新统计数据
-		if result.Validation.Passed {
-			passed++
+	for _, result := range results {
+		// 更新统计数据（跳过的测试不参与统计）
+		if result.Validation.Skipped {
+			skipped++
 		} else {
-			failed++
-			// 记录错误分布
-			errorDistribution[result.Validation.FailureReason]++
-		}
+			if result.Validation.Passed {
+				passed++
+			} else {
+				failed++
+				// 记录错误分布
+				errorDistribution[result.Validation.FailureReason]++
+			}
 
-		// 更新响应时间统计
-		responseTime := result.Validation.ResponseTime
-		totalResponseTime += responseTime
+			// 更新响应时间统计
+			responseTime := result.Validation.ResponseTime
+			totalResponseTime += responseTime
 
-		if i == 0 || responseTime < minResponseTime {
-			minResponseTime = responseTime
-		}
-		if responseTime > maxResponseTime {
-			maxResponseTime = responseTime
-		}
+			if passed+failed == 1 || responseTime < minResponseTime {
+				minResponseTime = responseTime
+			}
+			if responseTime > maxResponseTime {
+				maxResponseTime = responseTime
+			}
 
-		// 更新状态码分布
-		statusCode := fmt.Sprintf("%d", result.Validation.ActualStatus)
-		statusCodeDistribution[statusCode]++
+			// 更新状态码分布
+			statusCode := fmt.Sprintf("%d", result.Validation.ActualStatus)
+			statusCodeDistribution[statusCode]++
+		}
 
 		// 添加详细测试结果
 		testResult := TestResult{}
@@ -235,11 +273,41 @@ func prepareMachineReport(apiDef *parser.APIDefinition, results []*types.Endpoin
 
 		// 设置验证结果
 		testResult.Validation.Passed = result.Validation.Passed
+		testResult.Validation.Skipped = result.Validation.Skipped
+		testResult.Validation.Flaky = result.Validation.Flaky
+		testResult.Validation.Retries = result.Validation.Retries
+		testResult.Validation.SkipReason = result.Validation.SkipReason
 		testResult.Validation.FailureReason = result.Validation.FailureReason
 		testResult.Validation.ExpectedStatus = result.Validation.ExpectedStatus
 		testResult.Validation.ActualStatus = result.Validation.ActualStatus
 		testResult.Validation.ResponseTime = result.Validation.ResponseTime
 		testResult.Validation.ResponseBody = result.Validation.ResponseBody
+		for _, assertion := range result.Validation.Assertions {
+			testResult.Validation.Assertions = append(testResult.Validation.Assertions, Assertion(assertion))
+		}
+		for _, attempt := range result.Validation.Attempts {
+			testResult.Validation.Attempts = append(testResult.Validation.Attempts, Attempt{
+				Attempt:       attempt.Attempt,
+				StatusCode:    attempt.StatusCode,
+				ResponseTime:  attempt.ResponseTime,
+				Passed:        attempt.Passed,
+				FailureReason: attempt.FailureReason,
+				Timestamp:     attempt.Time.Format(time.RFC3339),
+			})
+		}
+
+		// 设置场景信息
+		testResult.Scenario = result.Scenario
+		testResult.Step = result.Step
+
+		// 单独列出不稳定的测试
+		if result.Validation.Flaky {
+			name := fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)
+			if result.Step != "" {
+				name = fmt.Sprintf("%s / %s", result.Scenario, result.Step)
+			}
+			report.Summary.FlakyTests = append(report.Summary.FlakyTests, name)
+		}
 
 		// 设置测试时间
 		testResult.Timestamp = result.TestTime.Format(time.RFC3339)
@@ -251,14 +319,16 @@ func prepareMachineReport(apiDef *parser.APIDefinition, results []*types.Endpoin
 	report.Summary.Total = total
 	report.Summary.Passed = passed
 	report.Summary.Failed = failed
+	report.Summary.Skipped = skipped
+	report.Summary.Flaky = len(report.Summary.FlakyTests)
 	report.Summary.TotalResponseTime = totalResponseTime
 	report.Summary.MinResponseTime = minResponseTime
 	report.Summary.MaxResponseTime = maxResponseTime
 
 	// 计算通过率和平均响应时间
-	if total > 0 {
-		report.Summary.PassRate = float64(passed) / float64(total) * 100
-		report.Summary.AvgResponseTime = float64(totalResponseTime) / float64(total)
+	if executed := passed + failed; executed > 0 {
+		report.Summary.PassRate = float64(passed) / float64(executed) * 100
+		report.Summary.AvgResponseTime = float64(totalResponseTime) / float64(executed)
 	}
 
 	// 设置错误分析
@@ -284,6 +354,9 @@ func prepareMachineReport(apiDef *parser.APIDefinition, results []*types.Endpoin
 
 	// 收集已测试端点
 	for _, result := range results {
+		if result.Validation.Skipped {
+			continue
+		}
 		endpoint := result.Endpoint.(*parser.Endpoint) // 类型断言
 		pathKey := fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)
 		testedPaths[pathKey] = true
@@ -325,6 +398,11 @@ type JUnitTestSuite struct {
 	TestCases  []JUnitTestCase `xml:"testcase"`
 }
 
+// JUnitProperties JUnit测试用例属性列表
+type JUnitProperties struct {
+	Property []JUnitProperty `xml:"property"`
+}
+
 // JUnitProperty JUnit属性结构
 type JUnitProperty struct {
 	Name  string `xml:"name,attr"`
@@ -333,10 +411,18 @@ type JUnitProperty struct {
 
 // JUnitTestCase JUnit测试用例结构
 type JUnitTestCase struct {
-	Name      string       `xml:"name,attr"`
-	Classname string       `xml:"classname,attr"`
-	Time      float64      `xml:"time,attr"`
-	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Name       string           `xml:"name,attr"`
+	Classname  string           `xml:"classname,attr"`
+	Time       float64          `xml:"time,attr"`
+	Properties *JUnitProperties `xml:"properties,omitempty"`
+	Skipped    *JUnitSkipped    `xml:"skipped,omitempty"`
+	Failure    *JUnitFailure    `xml:"failure,omitempty"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+// JUnitSkipped JUnit跳过信息结构
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
 }
 
 // JUnitFailure JUnit失败信息结构
@@ -347,7 +433,8 @@ type JUnitFailure struct {
 }
 
 // GenerateJUnitReport 生成 JUnit 格式的测试报告
-func GenerateJUnitReport(apiDef *parser.APIDefinition, results []*types.EndpointTestResult, outputDir string) (string, error) {
+// environment 为测试环境名称，为空时记为 "default"
+func GenerateJUnitReport(apiDef *parser.APIDefinition, results []*types.EndpointTestResult, outputDir string, environment string) (string, error) {
 
 	// 创建输出目录
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
@@ -368,10 +455,14 @@ func GenerateJUnitReport(apiDef *parser.APIDefinition, results []*types.Endpoint
 	testSuite.Properties = append(testSuite.Properties, JUnitProperty{
 		Name:  "version",
 		Value: apiDef.Version,
+	}, JUnitProperty{
+		Name:  "environment",
+		Value: environmentName(environment),
 	})
 
-	// 计算总时间和失败数
+	// 计算总时间、失败数和不稳定的测试数
 	totalTime := 0.0
+	flaky := 0
 	for _, result := range results {
 		endpoint := result.Endpoint.(*parser.Endpoint) // 类型断言
 		testCase := JUnitTestCase{
@@ -379,11 +470,29 @@ func GenerateJUnitReport(apiDef *parser.APIDefinition, results []*types.Endpoint
 			Classname: endpoint.OperationID,
 			Time:      float64(result.Validation.ResponseTime) / 1000.0, // 转换为秒
 		}
+		// 场景模式下使用场景和步骤名称
+		if result.Step != "" {
+			testCase.Name = fmt.Sprintf("%s (%s %s)", result.Step, endpoint.Method, endpoint.Path)
+			testCase.Classname = result.Scenario
+		}
 
 		totalTime += testCase.Time
 
-		// 如果测试失败，添加失败信息
-		if !result.Validation.Passed {
+		// 不稳定的测试通过属性和输出标记，方便 CI 统计
+		if result.Validation.Flaky {
+			flaky++
+			testCase.Properties = &JUnitProperties{Property: []JUnitProperty{
+				{Name: "flaky", Value: "true"},
+				{Name: "retries", Value: fmt.Sprintf("%d", result.Validation.Retries)},
+			}}
+			testCase.SystemOut = fmt.Sprintf("FLAKY: passed after %d retries", result.Validation.Retries)
+		}
+
+		// 如果测试跳过，添加跳过信息；如果测试失败，添加失败信息
+		if result.Validation.Skipped {
+			testSuite.Skipped++
+			testCase.Skipped = &JUnitSkipped{Message: result.Validation.SkipReason}
+		} else if !result.Validation.Passed {
 			testSuite.Failures++
 			testCase.Failure = &JUnitFailure{
 				Message: result.Validation.FailureReason,
@@ -391,12 +500,23 @@ func GenerateJUnitReport(apiDef *parser.APIDefinition, results []*types.Endpoint
 				Content: fmt.Sprintf("Expected status: %s, Actual status: %d", 
 					result.Validation.ExpectedStatus, result.Validation.ActualStatus),
 			}
+			// 列出所有失败的断言
+			for _, assertion := range result.Validation.Assertions {
+				if !assertion.Passed {
+					testCase.Failure.Content += fmt.Sprintf("\n%s %s %s, actual: %s",
+						assertion.Path, assertion.Operator, assertion.Expected, assertion.Actual)
+				}
+			}
 		}
 
 		testSuite.TestCases = append(testSuite.TestCases, testCase)
 	}
 
 	testSuite.Time = totalTime
+	testSuite.Properties = append(testSuite.Properties, JUnitProperty{
+		Name:  "flaky",
+		Value: fmt.Sprintf("%d", flaky),
+	})
 
 	// 生成报告文件名
 	reportFileName := fmt.Sprintf("junit-report-%s.xml", time.Now().Format("20060102-150405"))
@@ -425,3 +545,11 @@ func GenerateJUnitReport(apiDef *parser.APIDefinition, results []*types.Endpoint
 
 	return reportPath, nil
 }
+
+// environmentName 返回报告中显示的环境名称，未选择环境时为 "default"
+func environmentName(environment string) string {
+	if environment == "" {
+		return "default"
+	}
+	return environment
+}
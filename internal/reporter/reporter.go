@@ -30,7 +30,11 @@ type ReportData struct {
 	Passed int
 	// 失败测试数
 	Failed int
-	// 通过率
+	// 跳过测试数
+	Skipped int
+	// 不稳定（自动重试后才通过）的测试数，包含在通过数中
+	Flaky int
+	// 通过率（不含跳过的测试）
 	PassRate float64
 	// 总响应时间
 	TotalResponseTime int64
@@ -49,24 +53,33 @@ func GenerateReport(apiDef *parser.APIDefinition, results []*types.EndpointTestR
 	total := len(results)
 	passed := 0
 	failed := 0
+	skipped := 0
+	flaky := 0
 	totalResponseTime := int64(0)
 
 	for _, result := range results {
+		if result.Validation.Skipped {
+			skipped++
+			continue
+		}
 		if result.Validation.Passed {
 			passed++
+			if result.Validation.Flaky {
+				flaky++
+			}
 		} else {
 			failed++
 		}
 		totalResponseTime += result.Validation.ResponseTime
 	}
 
-	// 计算通过率和平均响应时间
+	// 计算通过率和平均响应时间（跳过的测试不参与计算）
 	passRate := 0.0
 	avgResponseTime := 0.0
 
-	if total > 0 {
-		passRate = float64(passed) / float64(total) * 100
-		avgResponseTime = float64(totalResponseTime) / float64(total)
+	if executed := passed + failed; executed > 0 {
+		passRate = float64(passed) / float64(executed) * 100
+		avgResponseTime = float64(totalResponseTime) / float64(executed)
 	}
 
 	// 创建报告数据
@@ -79,6 +92,8 @@ func GenerateReport(apiDef *parser.APIDefinition, results []*types.EndpointTestR
 		Total:             total,
 		Passed:            passed,
 		Failed:            failed,
+		Skipped:           skipped,
+		Flaky:             flaky,
 		PassRate:          passRate,
 		TotalResponseTime: totalResponseTime,
 		AvgResponseTime:   avgResponseTime,
@@ -175,6 +190,16 @@ const reportTemplate = `<!DOCTYPE html>
             background-color: #f8d7da;
             color: #721c24;
         }
+        .flaky {
+            background-color: #ffe5d0;
+            color: #8a4b08;
+        }
+        .flaky-badge {
+            margin-left: 8px;
+            padding: 2px 8px;
+            border-radius: 4px;
+            font-size: 0.85em;
+        }
         .response-time {
             background-color: #e2e3e5;
             color: #383d41;
@@ -198,6 +223,10 @@ const reportTemplate = `<!DOCTYPE html>
         .endpoint-failed {
             background-color: #f8d7da;
         }
+        .skipped, .endpoint-skipped {
+            background-color: #fff3cd;
+            color: #856404;
+        }
         .method {
             font-weight: bold;
             padding: 5px 10px;
@@ -233,6 +262,20 @@ const reportTemplate = `<!DOCTYPE html>
         }
         .status-2xx { color: #49cc90; }
         .status-4xx, .status-5xx { color: #f93e3e; }
+        .assertions {
+            width: 100%;
+            border-collapse: collapse;
+            margin: 10px 0;
+            font-size: 0.9em;
+        }
+        .assertions th, .assertions td {
+            border: 1px solid #ddd;
+            padding: 6px 8px;
+            text-align: left;
+            word-break: break-word;
+        }
+        .assertion-passed { color: #155724; }
+        .assertion-failed { color: #721c24; background-color: #f8d7da; }
         .footer {
             margin-top: 30px;
             text-align: center;
@@ -262,6 +305,14 @@ const reportTemplate = `<!DOCTYPE html>
             <h3>失败</h3>
             <p>{{.Failed}}</p>
         </div>
+        <div class="summary-card skipped">
+            <h3>跳过</h3>
+            <p>{{.Skipped}}</p>
+        </div>
+        <div class="summary-card flaky">
+            <h3>不稳定</h3>
+            <p>{{.Flaky}}</p>
+        </div>
         <div class="summary-card response-time">
             <h3>平均响应时间</h3>
             <p>{{printf "%.2f" .AvgResponseTime}} ms</p>
@@ -271,14 +322,20 @@ const reportTemplate = `<!DOCTYPE html>
     <h2>测试详情</h2>
     {{range $index, $result := .Results}}
     <div class="endpoint">
-        <div class="endpoint-header {{if $result.Validation.Passed}}endpoint-passed{{else}}endpoint-failed{{end}}" onclick="toggleDetails({{$index}})">
+        <div class="endpoint-header {{if $result.Validation.Skipped}}endpoint-skipped{{else if $result.Validation.Passed}}endpoint-passed{{else}}endpoint-failed{{end}}" onclick="toggleDetails({{$index}})">
             <div>
                 <span class="method {{lower $result.Endpoint.Method}}">{{$result.Endpoint.Method}}</span>
                 <span>{{$result.Endpoint.Path}}</span>
+                {{if $result.Step}}<span>- {{$result.Scenario}} / {{$result.Step}}</span>{{end}}
+                {{if $result.Validation.Flaky}}<span class="flaky flaky-badge">不稳定 (重试 {{$result.Validation.Retries}} 次)</span>{{end}}
             </div>
             <div>
+                {{if $result.Validation.Skipped}}
+                <span>已跳过</span>
+                {{else}}
                 <span class="status-code status-{{statusClass $result.Validation.ActualStatus}}">{{$result.Validation.ActualStatus}}</span>
                 <span>{{$result.Validation.ResponseTime}} ms</span>
+                {{end}}
             </div>
         </div>
         <div id="details-{{$index}}" class="endpoint-details">
@@ -288,12 +345,48 @@ const reportTemplate = `<!DOCTYPE html>
             <p><strong>标签:</strong> {{join $result.Endpoint.Tags ", "}}</p>
             {{end}}
             
-            {{if not $result.Validation.Passed}}
+            {{if $result.Validation.Skipped}}
+            <div style="background-color: #fff3cd; border-left: 4px solid #ffc107; padding: 12px; margin: 15px 0; border-radius: 4px;">
+                <h4 style="color: #856404; margin-top: 0;">⏭ 跳过原因</h4>
+                <p style="color: #856404; white-space: pre-wrap; word-break: break-word; margin-bottom: 0;">{{$result.Validation.SkipReason}}</p>
+            </div>
+            {{else if not $result.Validation.Passed}}
             <div style="background-color: #fff3cd; border-left: 4px solid #ffc107; padding: 12px; margin: 15px 0; border-radius: 4px;">
                 <h4 style="color: #856404; margin-top: 0;">❌ 失败原因</h4>
                 <p style="color: #721c24; font-family: 'Courier New', monospace; white-space: pre-wrap; word-break: break-word; margin-bottom: 0;">{{$result.Validation.FailureReason}}</p>
             </div>
             {{end}}
+
+            {{if $result.Validation.Assertions}}
+            <h4>断言结果</h4>
+            <table class="assertions">
+                <tr><th>目标</th><th>操作符</th><th>期望值</th><th>实际值</th><th>结果</th></tr>
+                {{range $result.Validation.Assertions}}
+                <tr class="{{if .Passed}}assertion-passed{{else}}assertion-failed{{end}}">
+                    <td>{{.Path}}</td>
+                    <td>{{.Operator}}</td>
+                    <td>{{.Expected}}</td>
+                    <td>{{.Actual}}</td>
+                    <td>{{if .Passed}}✔ 通过{{else}}✘ {{.Message}}{{end}}</td>
+                </tr>
+                {{end}}
+            </table>
+            {{end}}
+
+            {{if $result.Validation.Attempts}}
+            <h4>请求尝试 ({{len $result.Validation.Attempts}} 次)</h4>
+            <table class="assertions">
+                <tr><th>次数</th><th>状态码</th><th>响应时间</th><th>结果</th></tr>
+                {{range $result.Validation.Attempts}}
+                <tr class="{{if .Passed}}assertion-passed{{else}}assertion-failed{{end}}">
+                    <td>{{.Attempt}}</td>
+                    <td>{{.StatusCode}}</td>
+                    <td>{{.ResponseTime}} ms</td>
+                    <td>{{if .Passed}}✔ 满足条件{{else}}✘ {{.FailureReason}}{{end}}</td>
+                </tr>
+                {{end}}
+            </table>
+            {{end}}
             
             <h4>响应详情</h4>
             <p><strong>状态码:</strong> <span class="status-code status-{{statusClass $result.Validation.ActualStatus}}">{{$result.Validation.ActualStatus}}</span></p>
@@ -319,7 +412,7 @@ const reportTemplate = `<!DOCTYPE html>
         // 默认展开失败的测试
         document.addEventListener('DOMContentLoaded', function() {
             {{range $index, $result := .Results}}
-            {{if not $result.Validation.Passed}}
+            {{if and (not $result.Validation.Passed) (not $result.Validation.Skipped)}}
             document.getElementById('details-' + {{$index}}).classList.add('show');
             {{end}}
             {{end}}
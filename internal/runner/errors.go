@@ -0,0 +1,24 @@
+package runner
+
+import "fmt"
+
+// ConfigError 表示配置错误，如规范文件无效、过滤条件没有匹配的场景、场景定义有误等
+// 其他错误（如无法写入报告）视为运行环境错误
+type ConfigError struct {
+	Err error
+}
+
+// Error 实现 error 接口
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// configErrorf 创建配置错误
+func configErrorf(format string, args ...interface{}) error {
+	return &ConfigError{Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"github.com/gaoyong06/api-tester/internal/types"
+	"github.com/gaoyong06/api-tester/pkg/utils"
+)
+
+// maskSecrets 屏蔽测试结果中的密钥值，避免密钥写入 HTML、JSON 和 JUnit 报告
+func maskSecrets(results []*types.EndpointTestResult) {
+	for _, result := range results {
+		validation := result.Validation
+		if validation == nil {
+			continue
+		}
+
+		validation.ResponseBody = utils.MaskSecrets(validation.ResponseBody)
+		validation.FailureReason = utils.MaskSecrets(validation.FailureReason)
+		validation.SkipReason = utils.MaskSecrets(validation.SkipReason)
+		for i := range validation.Assertions {
+			assertion := &validation.Assertions[i]
+			assertion.Path = utils.MaskSecrets(assertion.Path)
+			assertion.Expected = utils.MaskSecrets(assertion.Expected)
+			assertion.Actual = utils.MaskSecrets(assertion.Actual)
+			assertion.Message = utils.MaskSecrets(assertion.Message)
+		}
+		for i := range validation.Attempts {
+			attempt := &validation.Attempts[i]
+			attempt.FailureReason = utils.MaskSecrets(attempt.FailureReason)
+		}
+	}
+}
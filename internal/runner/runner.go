@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -34,7 +35,15 @@ func NewRunner(cfg *config.Config) *Runner {
 }
 
 // Run 运行API测试
-func (r *Runner) Run() (*types.TestResult, error) {
+// ctx 被取消（如收到中断信号）或超过配置的截止时间后停止执行剩余的测试，
+// 但仍会执行清理步骤，并用已有的结果生成报告
+func (r *Runner) Run(ctx context.Context) (*types.TestResult, error) {
+	if r.config.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, r.config.Deadline, fmt.Errorf("超过运行截止时间 %v", r.config.Deadline))
+		defer cancel()
+	}
+
 	// 存储所有端点
 	allEndpoints := []*parser.Endpoint{}
 	
@@ -52,7 +61,7 @@ func (r *Runner) Run() (*types.TestResult, error) {
 				// 如果失败，尝试解析 Swagger 2.0 格式
 				apiDef, err = parser.ParseSwaggerFile(specFile)
 				if err != nil {
-					return nil, fmt.Errorf("解析规范文件 %s 失败: %v", specFile, err)
+					return nil, configErrorf("解析规范文件 %s 失败: %v", specFile, err)
 				}
 			}
 			
@@ -71,7 +80,7 @@ func (r *Runner) Run() (*types.TestResult, error) {
 			// 如果失败，尝试解析 Swagger 2.0 格式
 			apiDef, err = parser.ParseSwaggerFile(r.config.SpecFile)
 			if err != nil {
-				return nil, fmt.Errorf("解析规范文件失败: %v", err)
+				return nil, configErrorf("解析规范文件失败: %v", err)
 			}
 		}
 		
@@ -81,7 +90,7 @@ func (r *Runner) Run() (*types.TestResult, error) {
 		fmt.Printf("基础 URL: %s\n", r.config.BaseURL)
 		fmt.Printf("端点数量: %d\n\n", len(apiDef.Endpoints))
 	} else {
-		return nil, fmt.Errorf("未指定规范文件")
+		return nil, configErrorf("未指定规范文件")
 	}
 	
 	// 打印总端点数量
@@ -104,14 +113,53 @@ func (r *Runner) Run() (*types.TestResult, error) {
 		for i := range r.config.YamlConfig.Scenarios {
 			scenarios = append(scenarios, &r.config.YamlConfig.Scenarios[i])
 		}
+
+		// 按名称、步骤和标签过滤场景
+		filter := &scenario.Filter{
+			Scenarios:   r.config.ScenarioPatterns,
+			Steps:       r.config.StepPatterns,
+			Tags:        r.config.Tags,
+			ExcludeTags: r.config.ExcludeTags,
+		}
+		if !filter.IsEmpty() {
+			filtered, err := filter.Apply(scenarios)
+			if err != nil {
+				return nil, &ConfigError{Err: err}
+			}
+			if len(filtered) == 0 {
+				return nil, configErrorf("没有匹配过滤条件的测试场景")
+			}
+			fmt.Printf("过滤后运行 %d/%d 个测试场景\n\n", len(filtered), len(scenarios))
+			scenarios = filtered
+		}
+
+		// 只重新运行上次失败的步骤
+		if r.config.RerunFailed != "" {
+			previous, err := types.LoadTestResultsFromFile(r.config.RerunFailed)
+			if err != nil {
+				return nil, &ConfigError{Err: err}
+			}
+			failed := scenario.NewFailedSteps(previous.Results)
+			if failed.IsEmpty() {
+				return nil, configErrorf("%s 中没有失败的场景步骤", r.config.RerunFailed)
+			}
+			selected := failed.Select(scenarios)
+			if len(selected) == 0 {
+				return nil, configErrorf("%s 中失败的场景步骤在当前配置中都不存在", r.config.RerunFailed)
+			}
+			fmt.Printf("重新运行上次失败的 %d/%d 个测试场景\n\n", len(selected), len(scenarios))
+			scenarios = selected
+		}
 		
 		// 创建场景管理器，传递配置对象
 		scenarioManager := scenario.NewManager(scenarios, mergedApiDef, r.client, r.config.YamlConfig)
+		scenarioManager.Parallel = r.config.Parallel
+		scenarioManager.MaxFailures = r.config.MaxFailures
 		
 		// 运行所有场景
-		scenarioResults, err := scenarioManager.RunAllScenarios()
+		scenarioResults, err := scenarioManager.RunAllScenarios(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("运行测试场景失败: %v", err)
+			return nil, configErrorf("运行测试场景失败: %v", err)
 		}
 		
 		// 保存测试结果
@@ -122,6 +170,10 @@ func (r *Runner) Run() (*types.TestResult, error) {
 		
 		// 运行所有端点测试
 		for i, endpoint := range allEndpoints {
+			if ctx.Err() != nil {
+				fmt.Printf("停止运行剩余的 %d 个端点测试: %v\n", len(allEndpoints)-i, context.Cause(ctx))
+				break
+			}
 			fmt.Printf("[%d/%d] 测试 %s %s... ", i+1, len(allEndpoints), endpoint.Method, endpoint.Path)
 
 			// 提取路径参数和查询参数
@@ -133,7 +185,7 @@ func (r *Runner) Run() (*types.TestResult, error) {
 			queryParams := client.ExtractQueryParams(endpoint)
 
 			// 发送请求
-			response, err := r.client.SendRequest(endpoint, pathParams, queryParams)
+			response, err := r.client.SendRequest(ctx, endpoint, pathParams, queryParams, nil)
 			if err != nil {
 				fmt.Printf("发送请求出错: %v\n", err)
 				continue
@@ -158,30 +210,50 @@ func (r *Runner) Run() (*types.TestResult, error) {
 		}
 	}
 
+	// 屏蔽结果中的密钥值后再生成测试报告
+	maskSecrets(r.results)
+
 	// 生成测试报告
 	reportPath, err := reporter.GenerateReport(mergedApiDef, r.results, r.config.OutputDir)
 	if err != nil {
 		return nil, fmt.Errorf("生成测试报告失败: %v", err)
 	}
 
+	// 运行被取消或超过截止时间时，记录没有全部完成的原因
+	stopReason := ""
+	if ctx.Err() != nil {
+		stopReason = context.Cause(ctx).Error()
+	}
+
 	// 统计测试结果
 	total := len(r.results)
 	passed := 0
 	failed := 0
+	skipped := 0
+	flaky := 0
 
 	for _, result := range r.results {
-		if result.Validation.Passed {
+		if result.Validation.Skipped {
+			skipped++
+		} else if result.Validation.Passed {
 			passed++
+			if result.Validation.Flaky {
+				flaky++
+			}
 		} else {
 			failed++
 		}
 	}
 
 	return &types.TestResult{
-		Total:      total,
-		Passed:     passed,
-		Failed:     failed,
-		ReportPath: reportPath,
-		Results:    r.results, // 添加测试结果详情
+		Total:       total,
+		Passed:      passed,
+		Failed:      failed,
+		Skipped:     skipped,
+		Flaky:       flaky,
+		Environment: r.config.Environment,
+		StopReason:  stopReason,
+		ReportPath:  reportPath,
+		Results:     r.results, // 添加测试结果详情
 	}, nil
 }
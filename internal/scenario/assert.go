@@ -0,0 +1,266 @@
+package scenario
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gaoyong06/api-tester/internal/types"
+	"github.com/gaoyong06/api-tester/pkg/client"
+	"github.com/tidwall/gjson"
+)
+
+// absentValue 特殊期望值：断言响应头或 Cookie 不存在
+const absentValue = "!absent"
+
+// cookieAttributes Cookie 断言支持的属性名
+var cookieAttributes = map[string]bool{
+	"value":     true,
+	"path":      true,
+	"domain":    true,
+	"expires":   true,
+	"max_age":   true,
+	"secure":    true,
+	"http_only": true,
+	"same_site": true,
+}
+
+// validateHeader 验证响应头是否匹配期望值
+// 支持精确匹配、比较操作符、正则（=~）、"!null"（存在）和 "!absent"（不存在）
+func (m *Manager) validateHeader(response *client.Response, name string, expectedValue interface{}) types.AssertionResult {
+	label := "响应头 " + name
+	path := "header:" + name
+	values := http.Header(response.Headers).Values(name)
+	actual := strings.Join(values, ", ")
+
+	if isAbsentExpectation(expectedValue) {
+		if len(values) == 0 {
+			return newValueAssertion(path, expectedValue, missingActual, true, "")
+		}
+		return newValueAssertion(path, expectedValue, actual, false, fmt.Sprintf("%s: 期望不存在，实际值 \"%s\"", label, actual))
+	}
+
+	if len(values) == 0 {
+		return newValueAssertion(path, expectedValue, missingActual, false, fmt.Sprintf("%s 不存在于响应中", label))
+	}
+
+	passed, message := m.compareResult(label, stringResult(actual), expectedValue)
+	return newValueAssertion(path, expectedValue, actual, passed, message)
+}
+
+// validateContentType 验证响应的 Content-Type
+// 期望值不含参数（如 charset）时只比较媒体类型部分
+func (m *Manager) validateContentType(response *client.Response, expectedValue interface{}) types.AssertionResult {
+	label := "Content-Type"
+	path := "content_type"
+	actual := http.Header(response.Headers).Get("Content-Type")
+	if actual == "" {
+		return newValueAssertion(path, expectedValue, missingActual, false, fmt.Sprintf("%s 不存在于响应中", label))
+	}
+
+	if expected, ok := expectedValue.(string); ok && !strings.Contains(expected, ";") && !isOperatorExpression(expected) {
+		if mediaType, _, err := mime.ParseMediaType(actual); err == nil {
+			actual = mediaType
+		}
+		expectedValue = strings.ToLower(strings.TrimSpace(expected))
+	}
+
+	passed, message := m.compareResult(label, stringResult(actual), expectedValue)
+	return newValueAssertion(path, expectedValue, actual, passed, message)
+}
+
+// validateCookie 验证响应设置的 Cookie
+// 期望值可以是 Cookie 值的断言，也可以是包含 value、secure、http_only 等属性的对象
+func (m *Manager) validateCookie(response *client.Response, name string, expectedValue interface{}) types.AssertionResult {
+	label := "Cookie " + name
+	path := "cookie:" + name
+
+	var cookie *http.Cookie
+	for _, c := range response.Cookies {
+		if c.Name == name {
+			cookie = c
+		}
+	}
+
+	if isAbsentExpectation(expectedValue) {
+		if cookie == nil {
+			return newValueAssertion(path, expectedValue, missingActual, true, "")
+		}
+		return newValueAssertion(path, expectedValue, cookie.Value, false, fmt.Sprintf("%s: 期望不存在，实际值 \"%s\"", label, cookie.Value))
+	}
+
+	if cookie == nil {
+		return newValueAssertion(path, expectedValue, missingActual, false, fmt.Sprintf("%s 不存在于响应中", label))
+	}
+
+	attrs, ok := expectedValue.(map[string]interface{})
+	if !ok || !isCookieAttributeMap(attrs) {
+		passed, message := m.compareResult(label, stringResult(cookie.Value), expectedValue)
+		return newValueAssertion(path, expectedValue, cookie.Value, passed, message)
+	}
+
+	// 属性断言：所有属性都满足才算通过，失败信息合并显示
+	var failures []string
+	for _, attr := range sortedKeys(attrs) {
+		passed, message := m.compareResult(fmt.Sprintf("%s 的 %s 属性", label, attr), cookieAttribute(cookie, attr), attrs[attr])
+		if !passed {
+			failures = append(failures, message)
+		}
+	}
+
+	return newAssertion(path, "attributes", displayJSON(attrs), cookie.String(), len(failures) == 0, strings.Join(failures, "; "))
+}
+
+// isAbsentExpectation 判断期望值是否要求不存在（"!absent" 或 {exists: false}）
+func isAbsentExpectation(expectedValue interface{}) bool {
+	if expected, ok := expectedValue.(string); ok && expected == absentValue {
+		return true
+	}
+	return expectsAbsent(expectedValue)
+}
+
+// isCookieAttributeMap 判断期望值对象是否为 Cookie 属性断言
+func isCookieAttributeMap(attrs map[string]interface{}) bool {
+	for key := range attrs {
+		if !cookieAttributes[key] {
+			return false
+		}
+	}
+	return len(attrs) > 0
+}
+
+// cookieAttribute 获取 Cookie 的指定属性，转换为 gjson.Result 以复用比较逻辑
+func cookieAttribute(cookie *http.Cookie, attr string) gjson.Result {
+	switch attr {
+	case "value":
+		return stringResult(cookie.Value)
+	case "path":
+		return stringResult(cookie.Path)
+	case "domain":
+		return stringResult(cookie.Domain)
+	case "expires":
+		return stringResult(cookie.RawExpires)
+	case "max_age":
+		return numberResult(float64(cookie.MaxAge))
+	case "secure":
+		return boolResult(cookie.Secure)
+	case "http_only":
+		return boolResult(cookie.HttpOnly)
+	case "same_site":
+		switch cookie.SameSite {
+		case http.SameSiteLaxMode:
+			return stringResult("Lax")
+		case http.SameSiteStrictMode:
+			return stringResult("Strict")
+		case http.SameSiteNoneMode:
+			return stringResult("None")
+		default:
+			return stringResult("")
+		}
+	default:
+		return gjson.Result{}
+	}
+}
+
+// isOperatorExpression 判断期望值是否为比较操作符表达式
+func isOperatorExpression(expected string) bool {
+	for _, prefix := range []string{"!null", "=~", ">=", "<=", ">", "<"} {
+		if strings.HasPrefix(expected, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingActual 实际值不存在时在断言结果中的显示
+const missingActual = "(不存在)"
+
+// newAssertion 创建断言结果
+func newAssertion(path, operator, expected, actual string, passed bool, message string) types.AssertionResult {
+	return types.AssertionResult{
+		Path:     path,
+		Operator: operator,
+		Expected: expected,
+		Actual:   actual,
+		Passed:   passed,
+		Message:  message,
+	}
+}
+
+// newValueAssertion 根据期望值推断操作符并创建断言结果
+func newValueAssertion(path string, expectedValue interface{}, actual string, passed bool, message string) types.AssertionResult {
+	expected := displayJSON(expectedValue)
+	if str, ok := expectedValue.(string); ok {
+		expected = str
+	}
+	return newAssertion(path, assertionOperator(expectedValue), expected, actual, passed, message)
+}
+
+// assertionOperator 根据期望值推断断言操作符
+func assertionOperator(expectedValue interface{}) string {
+	if matcher, ok := isMatcher(expectedValue); ok {
+		return strings.Join(sortedKeys(matcher), ",")
+	}
+
+	expected, ok := expectedValue.(string)
+	if !ok {
+		return "eq"
+	}
+
+	switch {
+	case expected == "!null":
+		return "not_null"
+	case expected == absentValue:
+		return "absent"
+	case strings.HasPrefix(expected, "=~"):
+		return "matches"
+	}
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(expected, op) {
+			return op
+		}
+	}
+	return "eq"
+}
+
+// summarizeAssertions 汇总断言结果，返回 (是否全部通过, 失败原因汇总, 断言结果)
+func summarizeAssertions(assertions []types.AssertionResult) (bool, string, []types.AssertionResult) {
+	var failures []string
+	for _, assertion := range assertions {
+		if !assertion.Passed {
+			failures = append(failures, assertion.Message)
+		}
+	}
+	return len(failures) == 0, strings.Join(failures, "\n"), assertions
+}
+
+// sortedKeys 返回排序后的键列表，保证断言执行顺序稳定
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringResult 将字符串包装为 gjson.Result
+func stringResult(value string) gjson.Result {
+	return gjson.Result{Type: gjson.String, Str: value, Raw: strconv.Quote(value)}
+}
+
+// numberResult 将数字包装为 gjson.Result
+func numberResult(value float64) gjson.Result {
+	return gjson.Result{Type: gjson.Number, Num: value, Raw: strconv.FormatFloat(value, 'f', -1, 64)}
+}
+
+// boolResult 将布尔值包装为 gjson.Result
+func boolResult(value bool) gjson.Result {
+	if value {
+		return gjson.Result{Type: gjson.True, Raw: "true"}
+	}
+	return gjson.Result{Type: gjson.False, Raw: "false"}
+}
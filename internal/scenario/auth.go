@@ -0,0 +1,45 @@
+package scenario
+
+import (
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/pkg/client"
+	"github.com/gaoyong06/api-tester/pkg/utils"
+)
+
+// requestAuth 返回步骤使用的认证方式，优先级为：步骤 > 场景 > 全局
+// type: none 或都没有配置时返回 nil；字符串字段支持模板变量，渲染后的凭据在日志和报告中屏蔽
+func (m *Manager) requestAuth(scenario *yaml.Scenario, step *yaml.Step) *client.Auth {
+	auth := step.Auth
+	if auth == nil {
+		auth = scenario.Auth
+	}
+	if auth == nil && m.Config != nil {
+		auth = m.Config.Auth
+	}
+	if auth == nil || auth.Type == "none" {
+		return nil
+	}
+
+	result := &client.Auth{
+		Type:         auth.Type,
+		Username:     m.render(auth.Username),
+		Password:     m.render(auth.Password),
+		Token:        m.render(auth.Token),
+		Name:         m.render(auth.Name),
+		Value:        m.render(auth.Value),
+		In:           auth.In,
+		TokenURL:     m.render(auth.TokenURL),
+		ClientID:     m.render(auth.ClientID),
+		ClientSecret: m.render(auth.ClientSecret),
+		ClientAuth:   auth.ClientAuth,
+	}
+	for _, scope := range auth.Scopes {
+		result.Scopes = append(result.Scopes, m.render(scope))
+	}
+
+	for _, secret := range []string{result.Password, result.Token, result.Value, result.ClientSecret} {
+		utils.RegisterSecret(secret)
+	}
+
+	return result
+}
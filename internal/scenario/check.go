@@ -0,0 +1,314 @@
+package scenario
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+)
+
+// templateActionPattern 匹配模板动作 {{...}}
+var templateActionPattern = regexp.MustCompile(`{{(.*?)}}`)
+
+// templateFieldPattern 匹配模板动作中引用的变量，如 {{.user.id | upper}} 中的 user
+var templateFieldPattern = regexp.MustCompile(`(?:^|[\s(|])\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// checker 静态检查配置中的变量引用
+type checker struct {
+	config   *yaml.Config
+	problems []string
+	// 当前场景的认证配置
+	scenarioAuth *yaml.AuthConfig
+}
+
+// Check 静态检查配置中的变量引用，不发送请求
+// 按步骤的执行顺序跟踪已定义的变量：全局变量、default_values、data / matrix 的列、
+// before_all 和前面步骤提取的变量、循环的 item / index 以及步骤模板的参数，
+// 返回引用了未定义的变量和端点路径参数没有取值的问题，即严格模式下运行时会失败的步骤
+// if / unless 条件允许引用未定义的变量，不做检查
+func Check(config *yaml.Config) []string {
+	c := &checker{config: config}
+
+	global := make(map[string]bool)
+	for name := range config.Variables {
+		global[name] = true
+	}
+	for name := range config.DefaultValues {
+		global[name] = true
+	}
+
+	// before_all 提取的变量对所有场景可见
+	c.checkSteps("before_all", config.BeforeAll, global)
+
+	for i := range config.Scenarios {
+		c.checkScenario(&config.Scenarios[i], copyNames(global))
+	}
+
+	c.checkSteps("after_all", config.AfterAll, copyNames(global))
+
+	return c.problems
+}
+
+// checkScenario 检查场景的 setup、steps 和 teardown
+func (c *checker) checkScenario(scenario *yaml.Scenario, defined map[string]bool) {
+	location := "场景 " + scenario.Name
+
+	iterations, err := expandScenario(scenario)
+	if err != nil {
+		c.addProblem(location, fmt.Sprintf("数据无效: %v", err))
+	}
+	for _, iteration := range iterations {
+		for name := range iteration.variables {
+			defined[name] = true
+		}
+	}
+
+	start := len(c.problems)
+	c.checkText(location, "base_url", scenario.BaseURL, defined)
+	for key, value := range scenario.Headers {
+		c.checkText(location, "请求头 "+key, value, defined)
+	}
+	sort.Strings(c.problems[start:])
+
+	c.scenarioAuth = scenario.Auth
+	c.checkSteps(location+" [setup]", scenario.Setup, defined)
+	c.checkSteps(location, scenario.Steps, defined)
+	c.checkSteps(location+" [teardown]", scenario.Teardown, defined)
+	c.scenarioAuth = nil
+}
+
+// checkSteps 按顺序检查一组步骤，步骤提取的变量加入 defined
+func (c *checker) checkSteps(location string, steps []yaml.Step, defined map[string]bool) {
+	for i := range steps {
+		c.checkStep(location, &steps[i], defined)
+	}
+}
+
+// checkStep 检查单个步骤引用的变量
+func (c *checker) checkStep(location string, step *yaml.Step, defined map[string]bool) {
+	location = fmt.Sprintf("%s 步骤 %s", location, step.Name)
+
+	switch {
+	case step.Use != "":
+		template, exists := c.config.StepTemplates[step.Use]
+		if !exists {
+			c.addProblem(location, fmt.Sprintf("步骤模板 %s 不存在", step.Use))
+			return
+		}
+		c.checkValue(location, "with", step.With, defined)
+		locals := make(map[string]bool)
+		for name := range template.Params {
+			locals[name] = true
+		}
+		for name := range step.With {
+			locals[name] = true
+		}
+		c.checkNested(location, template.Steps, defined, locals)
+
+	case step.Call != "":
+		target := c.findScenario(step.Call)
+		if target == nil {
+			c.addProblem(location, fmt.Sprintf("被调用的场景 %s 不存在", step.Call))
+			return
+		}
+		c.checkValue(location, "with", step.With, defined)
+		// 被调用场景提取的变量会复制回当前场景，被调用场景本身单独检查
+		for _, name := range extractedNames(target.Setup, target.Steps, target.Teardown) {
+			defined[name] = true
+		}
+
+	case step.Foreach != "":
+		c.checkText(location, "foreach", step.Foreach, defined)
+		c.checkNested(location, step.Steps, defined, map[string]bool{"item": true, "index": true})
+
+	default:
+		// 同一个步骤的问题按字母顺序排列，避免受 map 遍历顺序影响
+		start := len(c.problems)
+		c.checkRequest(location, step, defined)
+		sort.Strings(c.problems[start:])
+		for name := range step.Extract {
+			defined[variableRoot(name)] = true
+		}
+	}
+}
+
+// checkNested 检查循环或步骤模板的嵌套步骤，locals 为只在嵌套步骤中可见的变量
+// 嵌套步骤提取的变量在之后的步骤中仍然可见
+func (c *checker) checkNested(location string, steps []yaml.Step, defined map[string]bool, locals map[string]bool) {
+	scope := copyNames(defined)
+	for name := range locals {
+		scope[name] = true
+	}
+
+	c.checkSteps(location+" >", steps, scope)
+
+	for name := range scope {
+		if !locals[name] {
+			defined[name] = true
+		}
+	}
+}
+
+// checkRequest 检查发送请求的步骤：端点、基础URL、参数、请求头、请求体、断言、提取路径和认证配置
+func (c *checker) checkRequest(location string, step *yaml.Step, defined map[string]bool) {
+	c.checkText(location, "endpoint", step.Endpoint, defined)
+	c.checkText(location, "base_url", step.BaseURL, defined)
+	for key, value := range step.PathParams {
+		c.checkText(location, "路径参数 "+key, value, defined)
+	}
+	for key, value := range step.QueryParams {
+		c.checkText(location, "查询参数 "+key, value, defined)
+	}
+	for key, value := range step.Headers {
+		c.checkText(location, "请求头 "+key, value, defined)
+	}
+	c.checkValue(location, "body", step.RequestBody, defined)
+	for path, expected := range step.Assert {
+		c.checkText(location, "断言 "+path, path, defined)
+		c.checkValue(location, "断言 "+path, expected, defined)
+	}
+	for name, path := range step.Extract {
+		c.checkText(location, "extract "+name, path, defined)
+	}
+	c.checkAuth(location, step, defined)
+
+	// 端点路径参数 {param} 依次使用 path_params、变量和 default_values 的值
+	endpoint := templateActionPattern.ReplaceAllString(step.Endpoint, "")
+	for _, match := range bracePlaceholderPattern.FindAllStringSubmatch(endpoint, -1) {
+		name := match[1]
+		if _, exists := step.PathParams[name]; !exists && !c.isDefined(name, defined) {
+			c.addProblem(location, fmt.Sprintf("路径参数 %s 没有取值", match[0]))
+		}
+	}
+}
+
+// checkAuth 检查步骤使用的认证配置（步骤 > 场景 > 全局）引用的变量
+func (c *checker) checkAuth(location string, step *yaml.Step, defined map[string]bool) {
+	auth := step.Auth
+	if auth == nil {
+		auth = c.scenarioAuth
+	}
+	if auth == nil {
+		auth = c.config.Auth
+	}
+	if auth == nil || auth.Type == "none" {
+		return
+	}
+
+	fields := []string{auth.Username, auth.Password, auth.Token, auth.Name, auth.Value, auth.TokenURL, auth.ClientID, auth.ClientSecret}
+	fields = append(fields, auth.Scopes...)
+	for _, value := range fields {
+		c.checkText(location, "auth", value, defined)
+	}
+}
+
+// checkValue 检查对象、数组或字符串中所有字符串引用的变量
+func (c *checker) checkValue(location, field string, value interface{}, defined map[string]bool) {
+	switch v := value.(type) {
+	case string:
+		c.checkText(location, field, v, defined)
+	case map[string]interface{}:
+		for key, item := range v {
+			c.checkText(location, field, key, defined)
+			c.checkValue(location, field, item, defined)
+		}
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			c.checkText(location, field, fmt.Sprint(key), defined)
+			c.checkValue(location, field, item, defined)
+		}
+	case []interface{}:
+		for _, item := range v {
+			c.checkValue(location, field, item, defined)
+		}
+	}
+}
+
+// checkText 检查字符串中的模板引用的变量
+func (c *checker) checkText(location, field, text string, defined map[string]bool) {
+	for _, action := range templateActionPattern.FindAllStringSubmatch(text, -1) {
+		for _, ref := range templateFieldPattern.FindAllStringSubmatch(action[1], -1) {
+			if !c.isDefined(ref[1], defined) {
+				c.addProblem(location, fmt.Sprintf("%s 引用了未定义的变量 %s", field, action[0]))
+			}
+		}
+	}
+}
+
+// isDefined 判断变量是否已定义，开启 fuzzy_variables 时相似名称也算已定义
+func (c *checker) isDefined(name string, defined map[string]bool) bool {
+	if defined[name] {
+		return true
+	}
+	if !c.config.FuzzyVariables {
+		return false
+	}
+	for candidate := range defined {
+		if candidate == toCamelCase(name) || candidate == toSnakeCase(name) || strings.EqualFold(candidate, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// findScenario 按名称查找场景
+func (c *checker) findScenario(name string) *yaml.Scenario {
+	for i := range c.config.Scenarios {
+		if c.config.Scenarios[i].Name == name {
+			return &c.config.Scenarios[i]
+		}
+	}
+	return nil
+}
+
+// addProblem 记录一个问题，相同的问题只记录一次
+func (c *checker) addProblem(location, problem string) {
+	message := fmt.Sprintf("%s: %s", location, problem)
+	for _, existing := range c.problems {
+		if existing == message {
+			return
+		}
+	}
+	c.problems = append(c.problems, message)
+}
+
+// extractedNames 返回一组步骤（包括嵌套步骤）提取的所有变量名，按名称排序
+func extractedNames(stepGroups ...[]yaml.Step) []string {
+	seen := make(map[string]bool)
+	var collect func(steps []yaml.Step)
+	collect = func(steps []yaml.Step) {
+		for _, step := range steps {
+			for name := range step.Extract {
+				seen[variableRoot(name)] = true
+			}
+			collect(step.Steps)
+		}
+	}
+	for _, steps := range stepGroups {
+		collect(steps)
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// variableRoot 返回点号路径的第一段，如 user.id 返回 user
+func variableRoot(path string) string {
+	root, _, _ := strings.Cut(path, ".")
+	return root
+}
+
+// copyNames 复制变量名集合
+func copyNames(names map[string]bool) map[string]bool {
+	copied := make(map[string]bool, len(names))
+	for name := range names {
+		copied[name] = true
+	}
+	return copied
+}
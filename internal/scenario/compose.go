@@ -0,0 +1,191 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/types"
+)
+
+// runTemplate 展开 use 引用的步骤模板并运行模板中的步骤
+// 模板参数只在模板步骤中有效，模板步骤提取的变量保留在当前场景中
+func (m *Manager) runTemplate(scenario *yaml.Scenario, step *yaml.Step) []*types.EndpointTestResult {
+	var template yaml.StepTemplate
+	var exists bool
+	if m.Config != nil {
+		template, exists = m.Config.StepTemplates[step.Use]
+	}
+	if !exists {
+		reason := fmt.Sprintf("未找到步骤模板: %s", step.Use)
+		return []*types.EndpointTestResult{m.failStep(scenario, step, reason)}
+	}
+
+	params, err := m.templateParams(template.Params, step.With)
+	if err != nil {
+		reason := fmt.Sprintf("步骤模板 %s 的参数无效: %v", step.Use, err)
+		return []*types.EndpointTestResult{m.failStep(scenario, step, reason)}
+	}
+
+	fmt.Printf("执行步骤模板: %s (%s)\n", step.Name, step.Use)
+
+	// 同一个模板可以被多次使用，每次使用前重新计算模板步骤的依赖状态
+	for _, nested := range template.Steps {
+		m.Context.ClearStepStatus(nested.Name)
+	}
+
+	// 模板参数只对模板步骤可见，并行执行的其他步骤不会看到或覆盖
+	results := m.withLocals(params).runSteps(scenario, template.Steps)
+
+	return nestResults(step.Name, results)
+}
+
+// runCall 在独立的上下文中运行 call 指定的场景，并把被调用场景提取的变量复制回当前场景
+func (m *Manager) runCall(scenario *yaml.Scenario, step *yaml.Step) []*types.EndpointTestResult {
+	target := m.findScenario(step.Call)
+	if target == nil {
+		reason := fmt.Sprintf("未找到被调用的场景: %s", step.Call)
+		return []*types.EndpointTestResult{m.failStep(scenario, step, reason)}
+	}
+
+	// 调用链从当前运行的场景开始
+	callStack := m.callStack
+	if len(callStack) == 0 {
+		callStack = []string{scenario.Name}
+	}
+	for _, name := range callStack {
+		if name == target.Name {
+			reason := fmt.Sprintf("场景循环调用: %s -> %s", strings.Join(callStack, " -> "), target.Name)
+			return []*types.EndpointTestResult{m.failStep(scenario, step, reason)}
+		}
+	}
+
+	params, err := m.templateParams(nil, step.With)
+	if err != nil {
+		reason := fmt.Sprintf("调用场景 %s 的参数无效: %v", step.Call, err)
+		return []*types.EndpointTestResult{m.failStep(scenario, step, reason)}
+	}
+
+	fmt.Printf("调用场景: %s -> %s\n", step.Name, target.Name)
+
+	called := m.forScenario()
+	called.callStack = append(append([]string{}, callStack...), target.Name)
+	for name, value := range params {
+		called.Context.SetVariable(name, value)
+	}
+
+	results, err := called.runScenarioSteps(target)
+	if err != nil {
+		reason := fmt.Sprintf("调用场景 %s 失败: %v", target.Name, err)
+		return []*types.EndpointTestResult{m.failStep(scenario, step, reason)}
+	}
+
+	// 复制被调用场景提取的变量
+	for name, value := range called.Context.ExtractedSnapshot() {
+		m.Context.SetExtracted(name, value)
+	}
+
+	// 被调用场景的失败已经计入失败数，这里只需处理当前场景的 stop_on_failure
+	if !allPassed(results) {
+		m.stopScenario(scenario, step.Name)
+	}
+
+	for _, result := range results {
+		result.Scenario = scenario.Name
+	}
+	return nestResults(step.Name, results)
+}
+
+// findScenario 按名称查找场景，包括未被选中运行的场景
+func (m *Manager) findScenario(name string) *yaml.Scenario {
+	for _, s := range m.Scenarios {
+		if s.Name == name {
+			return s
+		}
+	}
+	if m.Config != nil {
+		for i := range m.Config.Scenarios {
+			if m.Config.Scenarios[i].Name == name {
+				return &m.Config.Scenarios[i]
+			}
+		}
+	}
+	return nil
+}
+
+// templateParams 合并参数默认值和传入的参数，字符串参数支持模板变量
+// 默认值为空（~）的参数是必填参数
+func (m *Manager) templateParams(defaults map[string]interface{}, with map[string]interface{}) (map[string]interface{}, error) {
+	params := make(map[string]interface{}, len(defaults)+len(with))
+	for name, value := range defaults {
+		if value != nil {
+			params[name] = m.renderValue(value)
+		}
+	}
+	for name, value := range with {
+		params[name] = m.renderValue(value)
+	}
+
+	var missing []string
+	for name, value := range defaults {
+		if _, exists := params[name]; !exists && value == nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("缺少必填参数: %s", strings.Join(missing, ", "))
+	}
+
+	return params, nil
+}
+
+// summaryResult 汇总 use、call、foreach 步骤的嵌套步骤结果，供条件和模板通过 steps.<步骤名> 引用
+// 嵌套步骤都通过时通过，全部跳过时视为跳过；状态码为最后一个执行的嵌套步骤的状态码，响应时间为总和
+func summaryResult(scenario *yaml.Scenario, step *yaml.Step, results []*types.EndpointTestResult) *types.EndpointTestResult {
+	// 步骤本身未能执行（如模板不存在）时只有一个以步骤名命名的失败结果
+	if len(results) == 1 && results[0].Step == step.Name {
+		return results[0]
+	}
+
+	validation := &types.ValidationResult{Passed: true}
+	var failed []string
+	skipped := 0
+	for _, result := range results {
+		if result.Validation.Skipped {
+			skipped++
+			continue
+		}
+		validation.ActualStatus = result.Validation.ActualStatus
+		validation.ResponseTime += result.Validation.ResponseTime
+		if !result.Validation.Passed {
+			validation.Passed = false
+			failed = append(failed, result.Step)
+		}
+	}
+	if len(results) > 0 && skipped == len(results) {
+		validation.Skipped = true
+		validation.SkipReason = "所有嵌套步骤都被跳过"
+	}
+	if len(failed) > 0 {
+		validation.FailureReason = "嵌套步骤失败: " + strings.Join(failed, ", ")
+	}
+
+	return &types.EndpointTestResult{
+		Scenario:   scenario.Name,
+		Step:       step.Name,
+		Endpoint:   newStepEndpoint(step),
+		Validation: validation,
+		TestTime:   time.Now(),
+	}
+}
+
+// nestResults 将嵌套步骤的结果命名为 "步骤名 > 嵌套步骤名"
+func nestResults(stepName string, results []*types.EndpointTestResult) []*types.EndpointTestResult {
+	for _, result := range results {
+		result.Step = fmt.Sprintf("%s > %s", stepName, result.Step)
+	}
+	return results
+}
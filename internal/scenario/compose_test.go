@@ -0,0 +1,82 @@
+package scenario
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+)
+
+func TestTemplateParamsDoNotLeak(t *testing.T) {
+	server := newRecordingServer(t, nil)
+
+	lookup := getStep("查看", "/template", map[string]string{"user": "{{.user}}"})
+	lookup.Extract = map[string]string{"last": "$.query.user.0"}
+
+	after := getStep("之后", "/after", map[string]string{"user": "{{.user}}", "last": "{{.last}}"})
+	after.If = "steps.使用1.passed && steps.使用2.passed"
+
+	config := &yaml.Config{
+		StepTemplates: map[string]yaml.StepTemplate{
+			"查询": {Params: map[string]interface{}{"user": nil}, Steps: []yaml.Step{lookup}},
+		},
+		Scenarios: []yaml.Scenario{{
+			Name: "模板",
+			Steps: []yaml.Step{
+				{Name: "使用1", Use: "查询", With: map[string]interface{}{"user": "a"}},
+				{Name: "使用2", Use: "查询", With: map[string]interface{}{"user": "b"}},
+				after,
+			},
+		}},
+	}
+
+	results := runTestScenarios(t, server, config)
+	for _, result := range results {
+		if !result.Validation.Passed {
+			t.Errorf("step %s did not pass: %s %s", result.Step, result.Validation.FailureReason, result.Validation.SkipReason)
+		}
+	}
+
+	if got, want := server.query("/template", "user"), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/template user = %v, want %v", got, want)
+	}
+	// 模板参数不会留给之后的步骤，模板步骤提取的变量仍然可见
+	if got, want := server.query("/after", "user"), []string{"{{.user}}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/after user = %v, want %v", got, want)
+	}
+	if got, want := server.query("/after", "last"), []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/after last = %v, want %v", got, want)
+	}
+}
+
+func TestParallelTemplateParamsKeepSeparate(t *testing.T) {
+	server := newRecordingServer(t, nil)
+
+	config := &yaml.Config{
+		StepTemplates: map[string]yaml.StepTemplate{
+			"查询": {Params: map[string]interface{}{"user": nil}, Steps: []yaml.Step{
+				getStep("查看", "/template/{{.user}}", nil),
+				getStep("再看", "/template/{{.user}}/again", nil),
+			}},
+		},
+		Scenarios: []yaml.Scenario{{
+			Name:     "并行模板",
+			Parallel: 3,
+			Steps: []yaml.Step{
+				{Name: "使用1", Use: "查询", With: map[string]interface{}{"user": "a"}},
+				{Name: "使用2", Use: "查询", With: map[string]interface{}{"user": "b"}},
+				{Name: "使用3", Use: "查询", With: map[string]interface{}{"user": "c"}},
+			},
+		}},
+	}
+
+	for run := 0; run < 5; run++ {
+		server.reset()
+		runTestScenarios(t, server, config)
+
+		want := []string{"/template/a", "/template/a/again", "/template/b", "/template/b/again", "/template/c", "/template/c/again"}
+		if got := server.paths(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("paths = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package scenario
+
+import (
+	"strings"
+
+	"github.com/gaoyong06/api-tester/internal/types"
+)
+
+// 并行执行步骤时多个 goroutine 会同时读写上下文，
+// 因此上下文的变量、结果和步骤状态都通过以下加锁的方法访问。
+
+// withLocals 创建带局部变量的作用域上下文，局部变量不会写入共享的上下文，
+// 因此并行执行的循环或步骤模板不会互相覆盖 item、index 和模板参数
+func (c *Context) withLocals(locals map[string]interface{}) *Context {
+	merged := make(map[string]interface{}, len(c.locals)+len(locals))
+	for name, value := range c.locals {
+		merged[name] = value
+	}
+	for name, value := range locals {
+		merged[name] = value
+	}
+	return &Context{locals: merged, parent: c.shared()}
+}
+
+// shared 返回保存变量、结果和步骤状态的上下文
+func (c *Context) shared() *Context {
+	if c.parent != nil {
+		return c.parent
+	}
+	return c
+}
+
+// GetVariable 获取变量值，局部变量优先
+func (c *Context) GetVariable(name string) (interface{}, bool) {
+	if value, exists := c.locals[name]; exists {
+		return value, true
+	}
+	c = c.shared()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, exists := c.Variables[name]
+	return value, exists
+}
+
+// SetVariable 设置变量值
+func (c *Context) SetVariable(name string, value interface{}) {
+	c = c.shared()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Variables[name] = value
+}
+
+// SetExtracted 设置从响应中提取的变量，并记录为提取结果
+// 变量名包含点号（如 user.id）时设置嵌套对象变量 user 的 id 字段
+func (c *Context) SetExtracted(name string, value interface{}) {
+	c = c.shared()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	root, rest, nested := strings.Cut(name, ".")
+	if nested {
+		value = setNested(c.Variables[root], strings.Split(rest, "."), value)
+	}
+	c.Variables[root] = value
+	c.Extracted[root] = value
+}
+
+// setNested 返回在 parent 对象的 path 路径上设置 value 后的新对象
+// 沿路径的对象都会复制，不修改可能被其他场景共享的原对象；parent 不是对象时创建新对象
+func setNested(parent interface{}, path []string, value interface{}) map[string]interface{} {
+	original, _ := parent.(map[string]interface{})
+	object := make(map[string]interface{}, len(original)+1)
+	for key, item := range original {
+		object[key] = item
+	}
+	if len(path) == 1 {
+		object[path[0]] = value
+	} else {
+		object[path[0]] = setNested(object[path[0]], path[1:], value)
+	}
+	return object
+}
+
+// ExtractedSnapshot 返回提取变量的副本
+func (c *Context) ExtractedSnapshot() map[string]interface{} {
+	c = c.shared()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	snapshot := make(map[string]interface{}, len(c.Extracted))
+	for name, value := range c.Extracted {
+		snapshot[name] = value
+	}
+	return snapshot
+}
+
+// DeleteVariable 删除变量
+func (c *Context) DeleteVariable(name string) {
+	c = c.shared()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.Variables, name)
+}
+
+// VariablesSnapshot 返回变量（包括局部变量）的副本，用于需要遍历所有变量的场合
+func (c *Context) VariablesSnapshot() map[string]interface{} {
+	locals := c.locals
+	c = c.shared()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	snapshot := make(map[string]interface{}, len(c.Variables)+len(locals))
+	for name, value := range c.Variables {
+		snapshot[name] = value
+	}
+	for name, value := range locals {
+		snapshot[name] = value
+	}
+	return snapshot
+}
+
+// GetResult 获取步骤结果
+func (c *Context) GetResult(stepName string) (*types.EndpointTestResult, bool) {
+	c = c.shared()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result, exists := c.Results[stepName]
+	return result, exists
+}
+
+// SetResult 保存步骤结果
+func (c *Context) SetResult(stepName string, result *types.EndpointTestResult) {
+	c = c.shared()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Results[stepName] = result
+}
+
+// IsStepDone 判断步骤是否已执行完成
+func (c *Context) IsStepDone(stepName string) bool {
+	c = c.shared()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.StepStatus[stepName]
+}
+
+// MarkStepDone 标记步骤已执行完成
+func (c *Context) MarkStepDone(stepName string) {
+	c = c.shared()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.StepStatus[stepName] = true
+}
+
+// ClearStepStatus 清除步骤状态；不指定步骤时清除所有步骤状态
+func (c *Context) ClearStepStatus(stepNames ...string) {
+	c = c.shared()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(stepNames) == 0 {
+		c.StepStatus = make(map[string]bool)
+		return
+	}
+	for _, name := range stepNames {
+		delete(c.StepStatus, name)
+	}
+}
@@ -0,0 +1,170 @@
+package scenario
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/types"
+)
+
+// stepGraph 步骤依赖图，节点为步骤在场景中的下标
+type stepGraph struct {
+	// 每个步骤依赖的步骤
+	dependencies [][]int
+	// 依赖每个步骤的后续步骤
+	dependents [][]int
+}
+
+// buildStepGraph 根据 dependencies 构建步骤依赖图，依赖存在循环时返回错误
+// 依赖了不存在的步骤不算错误，执行时该步骤会因依赖未满足而跳过
+func buildStepGraph(steps []yaml.Step) (*stepGraph, error) {
+	index := make(map[string]int, len(steps))
+	for i, step := range steps {
+		if _, exists := index[step.Name]; !exists {
+			index[step.Name] = i
+		}
+	}
+
+	graph := &stepGraph{
+		dependencies: make([][]int, len(steps)),
+		dependents:   make([][]int, len(steps)),
+	}
+	for i, step := range steps {
+		for _, dep := range step.Dependencies {
+			j, exists := index[dep]
+			if !exists {
+				continue
+			}
+			graph.dependencies[i] = append(graph.dependencies[i], j)
+			graph.dependents[j] = append(graph.dependents[j], i)
+		}
+	}
+
+	if cycle := graph.findCycle(); cycle != nil {
+		names := make([]string, 0, len(cycle))
+		for _, i := range cycle {
+			names = append(names, steps[i].Name)
+		}
+		return nil, fmt.Errorf("步骤依赖存在循环: %s", strings.Join(names, " -> "))
+	}
+
+	return graph, nil
+}
+
+// findCycle 使用深度优先搜索查找依赖循环，返回循环经过的步骤（首尾相同），没有循环时返回 nil
+func (g *stepGraph) findCycle() []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(g.dependencies))
+	var path []int
+
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		path = append(path, i)
+
+		for _, dep := range g.dependencies[i] {
+			switch state[dep] {
+			case visiting:
+				// 从路径中找到循环的起点
+				for k, node := range path {
+					if node == dep {
+						return append(append([]int{}, path[k:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range g.dependencies {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
+
+// runStepsParallel 按依赖图并行运行步骤，同时运行的步骤不超过 workers 个
+// 一个步骤在它依赖的所有步骤结束后才开始，结果按步骤在配置中的顺序返回
+func (m *Manager) runStepsParallel(scenario *yaml.Scenario, steps []yaml.Step, workers int) []*types.EndpointTestResult {
+	graph, err := buildStepGraph(steps)
+	if err != nil {
+		fmt.Printf("警告: %v，改为顺序执行\n", err)
+		return m.runSteps(scenario, steps)
+	}
+
+	fmt.Printf("并行执行 %d 个步骤，最大并发数 %d\n", len(steps), workers)
+
+	remaining := make([]int, len(steps))
+	for i := range steps {
+		remaining[i] = len(graph.dependencies[i])
+	}
+
+	stepResults := make([][]*types.EndpointTestResult, len(steps))
+	semaphore := make(chan struct{}, workers)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	var schedule func(i int)
+	schedule = func(i int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			stepResults[i] = m.executeStep(scenario, steps[i])
+			<-semaphore
+
+			// 所有依赖都已结束的后续步骤可以开始执行
+			var ready []int
+			mu.Lock()
+			for _, next := range graph.dependents[i] {
+				remaining[next]--
+				if remaining[next] == 0 {
+					ready = append(ready, next)
+				}
+			}
+			mu.Unlock()
+
+			for _, next := range ready {
+				schedule(next)
+			}
+		}()
+	}
+
+	// 先确定没有依赖的步骤再开始调度，避免与已开始的步骤同时读写 remaining
+	var initial []int
+	for i := range steps {
+		if remaining[i] == 0 {
+			initial = append(initial, i)
+		}
+	}
+	for _, i := range initial {
+		schedule(i)
+	}
+	wg.Wait()
+
+	var results []*types.EndpointTestResult
+	for _, r := range stepResults {
+		results = append(results, r...)
+	}
+
+	return results
+}
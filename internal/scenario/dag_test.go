@@ -0,0 +1,60 @@
+package scenario
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+)
+
+// TestParallelSteps 并行执行互不依赖的步骤，每个步骤只执行一次，依赖的步骤先执行
+// 使用 go test -race 运行时同时检查调度和上下文的数据竞争
+func TestParallelSteps(t *testing.T) {
+	server := newRecordingServer(t, nil)
+
+	var steps []yaml.Step
+	var want []string
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("创建%d", i)
+		step := getStep(name, fmt.Sprintf("/create/%d", i), nil)
+		step.Extract = map[string]string{fmt.Sprintf("id%d", i): "$.path"}
+		steps = append(steps, step)
+
+		check := getStep(fmt.Sprintf("查询%d", i), fmt.Sprintf("/check/%d", i), map[string]string{"id": fmt.Sprintf("{{.id%d}}", i)})
+		check.Dependencies = []string{name}
+		steps = append(steps, check)
+
+		want = append(want, fmt.Sprintf("/check/%d", i), fmt.Sprintf("/create/%d", i))
+	}
+	summary := getStep("汇总", "/summary", nil)
+	summary.Dependencies = []string{"查询0", "查询7"}
+	steps = append(steps, summary)
+	want = append(want, "/summary")
+	sort.Strings(want)
+
+	config := &yaml.Config{Scenarios: []yaml.Scenario{{Name: "并行", Parallel: 4, Steps: steps}}}
+
+	for run := 0; run < 5; run++ {
+		server.reset()
+		results := runTestScenarios(t, server, config)
+
+		if len(results) != len(steps) {
+			t.Fatalf("got %d results, want %d", len(results), len(steps))
+		}
+		for _, result := range results {
+			if !result.Validation.Passed {
+				t.Errorf("step %s failed: %s", result.Step, result.Validation.FailureReason)
+			}
+		}
+		if got := server.paths(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("paths = %v, want %v", got, want)
+		}
+		for i := 0; i < 8; i++ {
+			if got := server.query(fmt.Sprintf("/check/%d", i), "id"); !reflect.DeepEqual(got, []string{fmt.Sprintf("/create/%d", i)}) {
+				t.Errorf("/check/%d id = %v", i, got)
+			}
+		}
+	}
+}
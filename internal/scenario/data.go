@@ -0,0 +1,150 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/mock"
+	"github.com/gaoyong06/api-tester/internal/types"
+)
+
+// maxRowLabelValue 迭代名称中单个数据值的最大显示长度
+const maxRowLabelValue = 20
+
+// dataIteration 数据驱动场景的一次迭代
+type dataIteration struct {
+	// 带行号的场景副本
+	scenario *yaml.Scenario
+	// 绑定到上下文的变量
+	variables map[string]interface{}
+}
+
+// runScenario 运行场景；配置了 data 或 matrix 时，每一行数据运行一次场景
+func (m *Manager) runScenario(scenario *yaml.Scenario) ([]*types.EndpointTestResult, error) {
+	iterations, err := expandScenario(scenario)
+	if err != nil {
+		return nil, fmt.Errorf("场景 %s 的数据无效: %v", scenario.Name, err)
+	}
+	if iterations == nil {
+		return m.runScenarioSteps(scenario)
+	}
+
+	fmt.Printf("场景 %s 包含 %d 行数据\n", scenario.Name, len(iterations))
+
+	var results []*types.EndpointTestResult
+	for _, iteration := range iterations {
+		iterationResults, err := m.runIteration(iteration)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, iterationResults...)
+	}
+
+	return results, nil
+}
+
+// runIteration 在独立的上下文中绑定数据行变量后运行场景
+// 每行数据以场景开始时的变量为初始值，上一行提取的变量、步骤结果和状态不会影响下一行；每次迭代单独判断 stop_on_failure
+func (m *Manager) runIteration(iteration dataIteration) ([]*types.EndpointTestResult, error) {
+	row := m.forScenario()
+	for name, value := range iteration.variables {
+		row.Context.SetVariable(name, value)
+	}
+
+	return row.runScenarioSteps(iteration.scenario)
+}
+
+// expandScenario 根据 data 或 matrix 将场景展开为多次迭代
+// 场景没有配置数据时返回 nil
+func expandScenario(scenario *yaml.Scenario) ([]dataIteration, error) {
+	var rows []map[string]interface{}
+	var err error
+
+	switch {
+	case scenario.Data != nil && len(scenario.Matrix) > 0:
+		return nil, fmt.Errorf("data 和 matrix 不能同时使用")
+	case scenario.Data != nil:
+		rows, err = loadScenarioData(scenario.Data)
+	case len(scenario.Matrix) > 0:
+		rows, err = expandMatrix(scenario.Matrix)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	iterations := make([]dataIteration, 0, len(rows))
+	for i, row := range rows {
+		iterationScenario := *scenario
+		iterationScenario.Name = fmt.Sprintf("%s [row %d: %s]", scenario.Name, i+1, rowLabel(row))
+		iterationScenario.Data = nil
+		iterationScenario.Matrix = nil
+		iterations = append(iterations, dataIteration{
+			scenario:  &iterationScenario,
+			variables: row,
+		})
+	}
+
+	return iterations, nil
+}
+
+// loadScenarioData 加载场景数据：字符串表示数据文件路径，数组表示内联数据
+func loadScenarioData(data interface{}) ([]map[string]interface{}, error) {
+	if path, ok := data.(string); ok {
+		return mock.LoadRows(path)
+	}
+	return mock.ToRows(data)
+}
+
+// expandMatrix 计算 matrix 中所有变量取值的组合，变量按名称排序展开
+func expandMatrix(matrix map[string][]interface{}) ([]map[string]interface{}, error) {
+	names := make([]string, 0, len(matrix))
+	for name, values := range matrix {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("matrix 变量 %s 没有取值", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := []map[string]interface{}{{}}
+	for _, name := range names {
+		var expanded []map[string]interface{}
+		for _, row := range rows {
+			for _, value := range matrix[name] {
+				next := make(map[string]interface{}, len(row)+1)
+				for k, v := range row {
+					next[k] = v
+				}
+				next[name] = value
+				expanded = append(expanded, next)
+			}
+		}
+		rows = expanded
+	}
+
+	return rows, nil
+}
+
+// rowLabel 生成数据行在迭代名称中的显示，如 "phone=138..., region=cn"
+func rowLabel(row map[string]interface{}) string {
+	keys := make([]string, 0, len(row))
+	for key := range row {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := fmt.Sprintf("%v", row[key])
+		if runes := []rune(value); len(runes) > maxRowLabelValue {
+			value = string(runes[:maxRowLabelValue]) + "..."
+		}
+		parts = append(parts, key+"="+value)
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,54 @@
+package scenario
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+)
+
+func TestDataRowsDoNotLeak(t *testing.T) {
+	server := newRecordingServer(t, nil)
+
+	record := getStep("记录", "/rows", map[string]string{"n": "{{.n}}"})
+	record.Extract = map[string]string{"prev": "$.query.n.0"}
+
+	config := &yaml.Config{Scenarios: []yaml.Scenario{
+		{
+			Name: "数据行",
+			Data: []interface{}{
+				map[string]interface{}{"n": 1},
+				map[string]interface{}{"n": 2},
+			},
+			Steps: []yaml.Step{
+				getStep("查看", "/seen", map[string]string{"n": "{{.n}}", "prev": "{{.prev}}"}),
+				record,
+			},
+		},
+		{
+			Name:  "之后",
+			Steps: []yaml.Step{getStep("之后", "/after", map[string]string{"n": "{{.n}}", "prev": "{{.prev}}"})},
+		},
+	}}
+
+	results := runTestScenarios(t, server, config)
+	if len(results) != 5 {
+		t.Fatalf("got %d results, want 5", len(results))
+	}
+
+	// 每行都能看到自己的数据，但看不到上一行提取的变量
+	if got, want := server.query("/seen", "n"), []string{"1", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/seen n = %v, want %v", got, want)
+	}
+	if got, want := server.query("/seen", "prev"), []string{"{{.prev}}", "{{.prev}}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/seen prev = %v, want %v", got, want)
+	}
+
+	// 数据行变量和行中提取的变量不会留给之后的场景
+	if got, want := server.query("/after", "n"), []string{"{{.n}}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/after n = %v, want %v", got, want)
+	}
+	if got, want := server.query("/after", "prev"), []string{"{{.prev}}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/after prev = %v, want %v", got, want)
+	}
+}
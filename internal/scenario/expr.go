@@ -0,0 +1,507 @@
+package scenario
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 条件表达式用于步骤的 if / unless，语法示例：
+//
+//	{{.user_id}} != ''
+//	steps.create_order.status == 201 && steps.create_order.passed
+//	!(vars.count > 10) || steps['查询订单'].skipped
+//
+// 支持的操作数：字符串（单引号或双引号）、数字、true/false/null、模板占位符 {{...}}、
+// 变量路径（vars.name 或直接写 name）以及步骤结果 steps.<步骤名>.<字段>。
+// 步骤结果字段：status、passed、failed、skipped、executed、response_time。
+
+// exprTokenKind 表达式词法单元类型
+type exprTokenKind int
+
+const (
+	tokenEOF exprTokenKind = iota
+	tokenString
+	tokenNumber
+	tokenIdent
+	tokenTemplate
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+// exprToken 表达式词法单元
+type exprToken struct {
+	kind  exprTokenKind
+	text  string
+	value interface{}
+}
+
+// templateVarPattern 匹配只包含单个变量引用的模板占位符，如 {{.user.id}}
+var templateVarPattern = regexp.MustCompile(`^{{\s*\.([a-zA-Z0-9_.]+)\s*}}$`)
+
+// exprParser 条件表达式解析器，解析的同时求值
+type exprParser struct {
+	manager *Manager
+	tokens  []exprToken
+	pos     int
+}
+
+// evaluateCondition 计算条件表达式，返回表达式的真假
+func (m *Manager) evaluateCondition(expression string) (bool, error) {
+	tokens, err := tokenizeExpression(expression)
+	if err != nil {
+		return false, err
+	}
+
+	p := &exprParser{manager: m, tokens: tokens}
+	value, err := p.parseOr()
+	if err != nil {
+		return false, err
+	}
+	if p.peek().kind != tokenEOF {
+		return false, fmt.Errorf("表达式 %q 在 %q 处存在多余内容", expression, p.peek().text)
+	}
+
+	return truthy(value), nil
+}
+
+// tokenizeExpression 将表达式拆分为词法单元
+func tokenizeExpression(expression string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '{' && i+1 < len(runes) && runes[i+1] == '{':
+			end := strings.Index(string(runes[i:]), "}}")
+			if end < 0 {
+				return nil, fmt.Errorf("表达式 %q 中的模板占位符未闭合", expression)
+			}
+			text := string(runes[i:])[:end+2]
+			tokens = append(tokens, exprToken{kind: tokenTemplate, text: text})
+			i += len([]rune(text))
+
+		case c == '\'' || c == '"':
+			j := i + 1
+			var sb strings.Builder
+			for j < len(runes) && runes[j] != c {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				sb.WriteRune(runes[j])
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("表达式 %q 中的字符串未闭合", expression)
+			}
+			tokens = append(tokens, exprToken{kind: tokenString, text: sb.String(), value: sb.String()})
+			i = j + 1
+
+		case c == '(':
+			tokens = append(tokens, exprToken{kind: tokenLParen, text: "("})
+			i++
+
+		case c == ')':
+			tokens = append(tokens, exprToken{kind: tokenRParen, text: ")"})
+			i++
+
+		case strings.ContainsRune("=!<>&|", c):
+			op := string(c)
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "==", "!=", ">=", "<=", "&&", "||":
+					op = two
+				}
+			}
+			if op == "=" || op == "&" || op == "|" {
+				return nil, fmt.Errorf("表达式 %q 中存在无效的操作符 %q", expression, op)
+			}
+			tokens = append(tokens, exprToken{kind: tokenOperator, text: op})
+			i += len(op)
+
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			text := string(runes[i:j])
+			number, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("表达式 %q 中的数字 %q 无效", expression, text)
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: text, value: number})
+			i = j
+
+		case isIdentRune(c):
+			// 标识符路径，允许 a.b.c 和 a['名称'] 形式
+			j := i
+			for j < len(runes) {
+				if isIdentRune(runes[j]) || runes[j] == '.' {
+					j++
+					continue
+				}
+				if runes[j] == '[' {
+					end := strings.IndexRune(string(runes[j:]), ']')
+					if end < 0 {
+						return nil, fmt.Errorf("表达式 %q 中的 [ 未闭合", expression)
+					}
+					j += len([]rune(string(runes[j:])[:end+1]))
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: string(runes[i:j])})
+			i = j
+
+		default:
+			return nil, fmt.Errorf("表达式 %q 中存在无法识别的字符 %q", expression, string(c))
+		}
+	}
+
+	return append(tokens, exprToken{kind: tokenEOF}), nil
+}
+
+// isIdentRune 判断字符是否可以出现在标识符中（支持中文步骤名）
+func isIdentRune(c rune) bool {
+	return c == '_' || c == '-' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	token := p.tokens[p.pos]
+	if token.kind != tokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+// parseOr 解析 a || b
+func (p *exprParser) parseOr() (interface{}, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOperator && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = truthy(left) || truthy(right)
+	}
+	return left, nil
+}
+
+// parseAnd 解析 a && b
+func (p *exprParser) parseAnd() (interface{}, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOperator && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = truthy(left) && truthy(right)
+	}
+	return left, nil
+}
+
+// parseUnary 解析 !a
+func (p *exprParser) parseUnary() (interface{}, error) {
+	if p.peek().kind == tokenOperator && p.peek().text == "!" {
+		p.next()
+		value, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return !truthy(value), nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison 解析 a == b、a > b 等比较
+func (p *exprParser) parseComparison() (interface{}, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.peek()
+	if token.kind != tokenOperator {
+		return left, nil
+	}
+	switch token.text {
+	case "==", "!=", ">", ">=", "<", "<=":
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return compareValues(left, token.text, right), nil
+}
+
+// parseOperand 解析操作数
+func (p *exprParser) parseOperand() (interface{}, error) {
+	token := p.next()
+	switch token.kind {
+	case tokenString, tokenNumber:
+		return token.value, nil
+	case tokenTemplate:
+		return p.manager.resolveTemplateOperand(token.text), nil
+	case tokenIdent:
+		return p.manager.resolveIdentifier(token.text), nil
+	case tokenLParen:
+		value, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenRParen {
+			return nil, fmt.Errorf("表达式缺少右括号")
+		}
+		return value, nil
+	case tokenEOF:
+		return nil, fmt.Errorf("表达式不完整，缺少操作数")
+	default:
+		return nil, fmt.Errorf("表达式在 %q 处缺少操作数", token.text)
+	}
+}
+
+// resolveTemplateOperand 解析模板占位符操作数
+// 单个变量引用保留变量的原始类型，变量不存在时返回 nil
+func (m *Manager) resolveTemplateOperand(text string) interface{} {
+	if match := templateVarPattern.FindStringSubmatch(text); match != nil {
+		value, _ := m.lookupVariable(match[1])
+		return value
+	}
+	return m.render(text)
+}
+
+// resolveIdentifier 解析标识符：true/false/null、steps.<步骤名>.<字段>、vars.<变量> 或变量名
+// 无法解析的标识符视为 null，与不存在的 {{.name}} 一致，避免拼错或未设置的变量被当作真值
+func (m *Manager) resolveIdentifier(ident string) interface{} {
+	switch ident {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null", "nil":
+		return nil
+	}
+
+	segments := splitIdentifier(ident)
+	if len(segments) >= 2 && segments[0] == "steps" {
+		return m.resolveStepField(segments[1], segments[2:])
+	}
+	if len(segments) >= 2 && segments[0] == "vars" {
+		segments = segments[1:]
+	}
+
+	if value, ok := m.lookupVariable(strings.Join(segments, ".")); ok {
+		return value
+	}
+	return nil
+}
+
+// resolveStepField 获取步骤结果字段
+func (m *Manager) resolveStepField(stepName string, fields []string) interface{} {
+	result, executed := m.Context.GetResult(stepName)
+	field := "executed"
+	if len(fields) > 0 {
+		field = fields[0]
+	}
+
+	switch field {
+	case "executed":
+		return executed && !result.Validation.Skipped
+	case "skipped":
+		return executed && result.Validation.Skipped
+	}
+
+	if !executed {
+		return nil
+	}
+	switch field {
+	case "status":
+		return float64(result.Validation.ActualStatus)
+	case "passed":
+		return result.Validation.Passed && !result.Validation.Skipped
+	case "failed":
+		return !result.Validation.Passed && !result.Validation.Skipped
+	case "response_time":
+		return float64(result.Validation.ResponseTime)
+	default:
+		return nil
+	}
+}
+
+// splitIdentifier 拆分标识符路径，支持 a.b 和 a['b c'] 形式
+func splitIdentifier(ident string) []string {
+	var segments []string
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, current.String())
+			current.Reset()
+		}
+	}
+
+	runes := []rune(ident)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '.':
+			flush()
+		case '[':
+			flush()
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			segments = append(segments, strings.Trim(string(runes[i+1:end]), `'"`))
+			i = end
+		default:
+			current.WriteRune(runes[i])
+		}
+	}
+	flush()
+
+	return segments
+}
+
+// lookupVariable 按点号路径查找上下文变量，支持嵌套对象和数组下标
+func (m *Manager) lookupVariable(path string) (interface{}, bool) {
+	segments := strings.Split(path, ".")
+	value, ok := m.Context.GetVariable(segments[0])
+	if !ok {
+		return nil, false
+	}
+
+	for _, segment := range segments[1:] {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if value, ok = v[segment]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// compareValues 比较两个操作数
+// 两边都能转换为数字时按数字比较，否则按字符串比较；nil 与空字符串视为相等
+func compareValues(left interface{}, op string, right interface{}) bool {
+	if left == nil || right == nil {
+		equal := (left == nil || left == "") && (right == nil || right == "")
+		switch op {
+		case "==":
+			return equal
+		case "!=":
+			return !equal
+		default:
+			return false
+		}
+	}
+
+	if l, lok := numericValue(left); lok {
+		if r, rok := numericValue(right); rok {
+			switch op {
+			case "==":
+				return l == r
+			case "!=":
+				return l != r
+			case ">":
+				return l > r
+			case ">=":
+				return l >= r
+			case "<":
+				return l < r
+			case "<=":
+				return l <= r
+			}
+		}
+	}
+
+	l, r := fmt.Sprintf("%v", left), fmt.Sprintf("%v", right)
+	switch op {
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	}
+	return false
+}
+
+// numericValue 将数字或数字字符串转换为 float64
+func numericValue(value interface{}) (float64, bool) {
+	if _, isBool := value.(bool); isBool {
+		return 0, false
+	}
+	return toFloat(value)
+}
+
+// truthy 判断值的真假
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != "" && v != "false" && v != "0"
+	case float64:
+		return v != 0
+	case int:
+		return v != 0
+	case int64:
+		return v != 0
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() > 0
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return rv.Int() != 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() != 0
+	case reflect.Float32:
+		return rv.Float() != 0
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package scenario
+
+import (
+	"testing"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/parser"
+	"github.com/gaoyong06/api-tester/internal/types"
+)
+
+func newConditionManager() *Manager {
+	m := NewManager(nil, &parser.APIDefinition{}, nil, &yaml.Config{Variables: map[string]interface{}{
+		"name":     "alice",
+		"empty":    "",
+		"zero_str": "0",
+		"count":    int64(0),
+		"total":    int64(3),
+		"ratio":    0.5,
+		"flag":     true,
+		"list":     []interface{}{},
+		"user":     map[string]interface{}{"id": 5.0, "roles": []interface{}{"admin"}},
+	}})
+	m.Context.SetResult("创建订单", &types.EndpointTestResult{Validation: &types.ValidationResult{Passed: true, ActualStatus: 201, ResponseTime: 120}})
+	m.Context.SetResult("查询订单", &types.EndpointTestResult{Validation: &types.ValidationResult{Skipped: true}})
+	m.Context.SetResult("删除订单", &types.EndpointTestResult{Validation: &types.ValidationResult{Passed: false, ActualStatus: 500}})
+	return m
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	m := newConditionManager()
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		// 字面量和真值判断
+		{"true", true},
+		{"false", false},
+		{"null", false},
+		{"'x'", true},
+		{"''", false},
+		{"0", false},
+		{"flag", true},
+		{"name", true},
+		{"empty", false},
+		{"zero_str", false},
+		{"list", false},
+		{"count", false},
+		{"total", true},
+		{"vars.total", true},
+
+		// 优先级：! 高于比较，比较高于 &&，&& 高于 ||
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"!false && false", false},
+		{"!(false && false)", true},
+		{"!flag || total > 2", true},
+		{"total > 2 && name == 'alice' || false", true},
+		{"!!flag", true},
+
+		// 不同类型的比较
+		{"total == 3", true},
+		{"total == '3'", true},
+		{"total >= 3.0", true},
+		{"ratio < 1", true},
+		{"count == 0", true},
+		{"'10' > '9'", true},
+		{"'abc' < 'abd'", true},
+		{"name == 'alice'", true},
+		{"name != \"alice\"", false},
+		{"flag == true", true},
+		{"flag == 'true'", true},
+		{"user.id == 5", true},
+		{"user.roles.0 == 'admin'", true},
+		{"{{.user.id}} > 4", true},
+		{"{{.name}} == 'alice'", true},
+		{"-1 < count", true},
+
+		// 无法解析的标识符和变量视为 null
+		{"missing", false},
+		{"!missing", true},
+		{"missing == null", true},
+		{"missing == ''", true},
+		{"missing != ''", false},
+		{"missing > 0", false},
+		{"user.missing", false},
+		{"{{.missing}} == null", true},
+		{"tokn == 'alice'", false},
+
+		// 步骤结果
+		{"steps.创建订单.passed", true},
+		{"steps.创建订单.status == 201", true},
+		{"steps['创建订单'].response_time < 200", true},
+		{"steps.创建订单", true},
+		{"steps.查询订单.skipped", true},
+		{"steps.查询订单.executed", false},
+		{"steps.删除订单.failed && steps.删除订单.status >= 500", true},
+		{"steps.未执行.executed", false},
+		{"steps.未执行.status == null", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := m.evaluateCondition(tt.expression)
+			if err != nil {
+				t.Fatalf("evaluateCondition(%q) error: %v", tt.expression, err)
+			}
+			if got != tt.want {
+				t.Errorf("evaluateCondition(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateConditionErrors(t *testing.T) {
+	m := newConditionManager()
+
+	for _, expression := range []string{
+		"",
+		"name ==",
+		"&& flag",
+		"(flag",
+		"flag)",
+		"flag flag",
+		"name = 'alice'",
+		"flag & true",
+		"flag | true",
+		"'unterminated",
+		"{{.name",
+		"steps['创建订单'.passed",
+		"name # 1",
+	} {
+		t.Run(expression, func(t *testing.T) {
+			if _, err := m.evaluateCondition(expression); err == nil {
+				t.Errorf("evaluateCondition(%q) should fail", expression)
+			}
+		})
+	}
+}
+
+func TestTruthy(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  bool
+	}{
+		{nil, false},
+		{int64(0), false},
+		{int64(2), true},
+		{int32(0), false},
+		{uint(1), true},
+		{float32(0), false},
+		{0, false},
+		{1.5, true},
+		{map[string]interface{}{}, false},
+		{[]interface{}{1}, true},
+		{struct{}{}, true},
+	}
+
+	for _, tt := range tests {
+		if got := truthy(tt.value); got != tt.want {
+			t.Errorf("truthy(%#v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gaoyong06/api-tester/internal/jsonpath"
+	"github.com/gaoyong06/api-tester/pkg/client"
+	"github.com/tidwall/gjson"
+)
+
+// arrayIndexPattern 匹配路径中的数组下标，如 items[0] 中的 [0]
+var arrayIndexPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// regexFilterPattern 匹配提取来源后的正则过滤，如 header:Location | regex:"/users/(\d+)"
+var regexFilterPattern = regexp.MustCompile(`^(.*?)\s*\|\s*regex:(.*)$`)
+
+// extractVariables 从响应中提取变量
+// 变量名包含点号（如 user.id）时提取到嵌套对象变量中
+func (m *Manager) extractVariables(extractors map[string]string, response *client.Response) {
+	names := make([]string, 0, len(extractors))
+	for name := range extractors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// 只渲染 {{...}} 模板，正则表达式中的 {n} 量词保持不变
+		source := strings.TrimSpace(m.renderTemplate(extractors[name]))
+		fmt.Printf("尝试从 %s 提取变量 %s\n", source, name)
+
+		value, err := m.extractValue(source, response)
+		if err != nil {
+			// 提取失败时不设置变量，teardown 步骤可以用 if: "{{.变量名}} != ''" 判断资源是否创建成功
+			fmt.Printf("  警告: 无法从 %s 提取变量 %s: %v\n", source, name, err)
+			m.recordProblem(fmt.Sprintf("无法从 %s 提取变量 %s: %v", source, name, err))
+			continue
+		}
+
+		m.Context.SetExtracted(name, value)
+		fmt.Printf("  成功提取变量: %s = %v\n", name, masked(value))
+	}
+}
+
+// extractValue 按提取来源取值：
+// status、body、header:名称、cookie:名称、regex:正则表达式、jsonpath:JSONPath 表达式，
+// 其他情况按 gjson 路径从 JSON 响应体中提取
+// 来源后可以加 | regex:正则表达式，对取到的值再用正则表达式匹配
+func (m *Manager) extractValue(source string, response *client.Response) (interface{}, error) {
+	if match := regexFilterPattern.FindStringSubmatch(source); match != nil && !strings.HasPrefix(source, "regex:") {
+		value, err := m.extractValue(match[1], response)
+		if err != nil {
+			return nil, err
+		}
+		text, ok := value.(string)
+		if !ok {
+			text = fmt.Sprint(value)
+		}
+		return extractRegex(unquoteExpression(strings.TrimSpace(match[2])), []byte(text))
+	}
+
+	kind, argument, _ := strings.Cut(source, ":")
+	argument = strings.TrimSpace(argument)
+
+	switch kind {
+	case "status":
+		if argument == "" {
+			return response.StatusCode, nil
+		}
+	case "body":
+		if argument == "" {
+			return string(response.Body), nil
+		}
+	case "header":
+		values := http.Header(response.Headers).Values(argument)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("响应头 %s 不存在", argument)
+		}
+		return values[0], nil
+	case "cookie":
+		var cookie *http.Cookie
+		for _, c := range response.Cookies {
+			if c.Name == argument {
+				cookie = c
+			}
+		}
+		if cookie == nil {
+			return nil, fmt.Errorf("Cookie %s 不存在", argument)
+		}
+		return cookie.Value, nil
+	case "regex":
+		return extractRegex(unquoteExpression(argument), response.Body)
+	case "jsonpath":
+		return extractJSONPath(argument, response.Body)
+	}
+
+	return extractPath(source, response.Body)
+}
+
+// extractRegex 用正则表达式匹配文本，有捕获组时返回第一个捕获组，否则返回整个匹配
+func extractRegex(pattern string, text []byte) (interface{}, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("无效的正则表达式: %v", err)
+	}
+	match := re.FindSubmatch(text)
+	if match == nil {
+		return nil, fmt.Errorf("没有匹配正则表达式 %s 的内容", pattern)
+	}
+	if len(match) > 1 {
+		return string(match[1]), nil
+	}
+	return string(match[0]), nil
+}
+
+// extractJSONPath 用 JSONPath 表达式从 JSON 响应体中提取值
+// 只匹配一个值时返回该值，匹配多个值时返回数组；确定路径（不含通配符、切片、过滤器和递归查找）总是返回单个值
+func extractJSONPath(expression string, body []byte) (interface{}, error) {
+	path, err := jsonpath.Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("响应不是有效的 JSON")
+	}
+
+	matches := path.Query(data)
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("没有匹配的值")
+	case len(matches) == 1 || path.Definite():
+		return matches[0], nil
+	default:
+		return matches, nil
+	}
+}
+
+// extractPath 按 gjson 路径从 JSON 响应体中提取值，$.items[0].id 形式的路径会转换为 items.0.id
+func extractPath(path string, body []byte) (interface{}, error) {
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("响应不是有效的 JSON")
+	}
+
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = arrayIndexPattern.ReplaceAllString(path, ".$1")
+
+	var result gjson.Result
+	if path == "" {
+		result = gjson.ParseBytes(body)
+	} else {
+		result = gjson.GetBytes(body, path)
+	}
+	if !result.Exists() {
+		return nil, fmt.Errorf("路径 %s 不存在", path)
+	}
+	return result.Value(), nil
+}
+
+// unquoteExpression 去除表达式两端成对的单引号或双引号
+func unquoteExpression(expression string) string {
+	if len(expression) >= 2 {
+		first, last := expression[0], expression[len(expression)-1]
+		if first == last && (first == '"' || first == '\'') {
+			return expression[1 : len(expression)-1]
+		}
+	}
+	return expression
+}
@@ -0,0 +1,150 @@
+package scenario
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/parser"
+	"github.com/gaoyong06/api-tester/pkg/client"
+)
+
+// newExtractResponse 创建用于测试提取变量的响应
+func newExtractResponse() *client.Response {
+	return &client.Response{
+		StatusCode: 201,
+		Headers: map[string][]string{
+			"Location":     {"/users/42"},
+			"X-Request-Id": {"abc", "def"},
+		},
+		Cookies: []*http.Cookie{
+			{Name: "session", Value: "s1"},
+			{Name: "theme", Value: "light"},
+			{Name: "theme", Value: "dark"},
+		},
+		Body: []byte(`{"id": 7, "user": {"name": "alice", "tags": ["a", "b"]}, "items": [{"id": 1}, {"id": 2}], "message": "order 123 created"}`),
+	}
+}
+
+func TestExtractValue(t *testing.T) {
+	m := NewManager(nil, &parser.APIDefinition{}, nil, &yaml.Config{})
+	response := newExtractResponse()
+	text := &client.Response{StatusCode: 200, Body: []byte("token=xyz; expires=3600")}
+
+	tests := []struct {
+		name     string
+		source   string
+		response *client.Response
+		want     interface{}
+	}{
+		{"status", "status", response, 201},
+		{"body", "body", text, "token=xyz; expires=3600"},
+		{"header", "header:Location", response, "/users/42"},
+		{"header is case-insensitive", "header: location", response, "/users/42"},
+		{"header with several values", "header:X-Request-Id", response, "abc"},
+		{"cookie", "cookie:session", response, "s1"},
+		{"last cookie with the same name", "cookie:theme", response, "dark"},
+		{"regex capture group", `regex:"order (\d+)"`, response, "123"},
+		{"regex without group", `regex:order \d+`, response, "order 123"},
+		{"regex on text body", `regex:token=(\w+)`, text, "xyz"},
+		{"regex with quantifier", `regex:'(\d{2})'`, text, "36"},
+		{"jsonpath", "jsonpath:$.user.name", response, "alice"},
+		{"jsonpath definite index", "jsonpath:$.items[0].id", response, 1.0},
+		{"jsonpath several matches", "jsonpath:$.items[*].id", response, []interface{}{1.0, 2.0}},
+		{"jsonpath single filter match", "jsonpath:$.items[?(@.id > 1)].id", response, 2.0},
+		{"path", "$.user.name", response, "alice"},
+		{"path with index", "$.user.tags[1]", response, "b"},
+		{"gjson path", "items.1.id", response, 2.0},
+		{"whole body as json", "$", &client.Response{Body: []byte(`[1]`)}, []interface{}{1.0}},
+		{"header filtered by regex", `header:Location | regex:"/users/(\d+)"`, response, "42"},
+		{"status filtered by regex", `status | regex:^(\d)`, response, "2"},
+		{"path filtered by regex", `$.message|regex:'\d+'`, response, "123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.extractValue(tt.source, tt.response)
+			if err != nil {
+				t.Fatalf("extractValue(%q) error = %v", tt.source, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractValue(%q) = %#v, want %#v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractValueErrors(t *testing.T) {
+	m := NewManager(nil, &parser.APIDefinition{}, nil, &yaml.Config{})
+	response := newExtractResponse()
+	text := &client.Response{StatusCode: 200, Body: []byte("not json")}
+
+	tests := []struct {
+		name     string
+		source   string
+		response *client.Response
+		wantErr  string
+	}{
+		{"missing header", "header:X-Missing", response, "响应头 X-Missing 不存在"},
+		{"missing cookie", "cookie:missing", response, "Cookie missing 不存在"},
+		{"invalid regex", "regex:(", response, "无效的正则表达式"},
+		{"regex without match", `regex:"user (\d+)"`, response, "没有匹配正则表达式"},
+		{"invalid jsonpath", "jsonpath:items", response, "JSONPath 必须以 $ 开头"},
+		{"jsonpath without match", "jsonpath:$.missing", response, "没有匹配的值"},
+		{"jsonpath on text body", "jsonpath:$.id", text, "响应不是有效的 JSON"},
+		{"missing path", "$.user.email", response, "路径 user.email 不存在"},
+		{"path on text body", "$.id", text, "响应不是有效的 JSON"},
+		{"regex filter on missing source", `header:X-Missing | regex:(\d+)`, response, "响应头 X-Missing 不存在"},
+		{"regex filter without match", `header:Location | regex:"/orders/(\d+)"`, response, "没有匹配正则表达式"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.extractValue(tt.source, tt.response)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("extractValue(%q) error = %v, want %q", tt.source, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractVariablesNested(t *testing.T) {
+	user := map[string]interface{}{"name": "old", "role": "admin"}
+	m := NewManager(nil, &parser.APIDefinition{}, nil, &yaml.Config{Variables: map[string]interface{}{"user": user}})
+
+	m.extractVariables(map[string]string{
+		"user.id":           "$.id",
+		"user.profile.name": "$.user.name",
+		"user.profile.tag":  "$.user.tags[0]",
+		"location":          "header:Location",
+		"missing":           "header:X-Missing",
+	}, newExtractResponse())
+
+	want := map[string]interface{}{
+		"name":    "old",
+		"role":    "admin",
+		"id":      7.0,
+		"profile": map[string]interface{}{"name": "alice", "tag": "a"},
+	}
+	if got, _ := m.Context.GetVariable("user"); !reflect.DeepEqual(got, want) {
+		t.Errorf("user = %#v, want %#v", got, want)
+	}
+	// 嵌套变量复制后再修改，不影响配置中的原对象
+	if len(user) != 2 {
+		t.Errorf("original user variable was modified: %#v", user)
+	}
+
+	extracted := m.Context.ExtractedSnapshot()
+	if !reflect.DeepEqual(extracted["user"], want) || extracted["location"] != "/users/42" {
+		t.Errorf("extracted = %#v", extracted)
+	}
+	// 提取失败时不设置变量
+	if _, exists := m.Context.GetVariable("missing"); exists {
+		t.Error("variable missing is set although its header does not exist")
+	}
+	if _, exists := extracted["missing"]; exists {
+		t.Error("variable missing is recorded as extracted")
+	}
+}
@@ -0,0 +1,267 @@
+package scenario
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+)
+
+// Filter 场景和步骤过滤条件，所有条件都支持 glob 通配符（如 "payment*"）
+type Filter struct {
+	// 场景名称，匹配任意一个即可
+	Scenarios []string
+	// 步骤名称，只运行匹配的步骤及其依赖的步骤
+	Steps []string
+	// 场景标签，场景的任意一个标签匹配即可
+	Tags []string
+	// 排除的场景标签，场景的任意一个标签匹配即排除
+	ExcludeTags []string
+}
+
+// IsEmpty 判断是否没有设置任何过滤条件
+func (f *Filter) IsEmpty() bool {
+	return len(f.Scenarios) == 0 && len(f.Steps) == 0 && len(f.Tags) == 0 && len(f.ExcludeTags) == 0
+}
+
+// Apply 按过滤条件筛选场景
+// 设置了步骤过滤时返回的是只包含匹配步骤及其依赖的场景副本，没有匹配步骤的场景会被去掉
+func (f *Filter) Apply(scenarios []*yaml.Scenario) ([]*yaml.Scenario, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	var selected []*yaml.Scenario
+	for _, scenario := range scenarios {
+		if len(f.Scenarios) > 0 && !matchAny(f.Scenarios, scenario.Name) {
+			continue
+		}
+		if len(f.Tags) > 0 && !matchAnyTag(f.Tags, scenario.Tags) {
+			continue
+		}
+		if matchAnyTag(f.ExcludeTags, scenario.Tags) {
+			continue
+		}
+
+		if len(f.Steps) > 0 {
+			steps := selectSteps(scenario.Steps, f.Steps)
+			if len(steps) == 0 {
+				continue
+			}
+			scenarioCopy := *scenario
+			scenarioCopy.Steps = steps
+			scenario = &scenarioCopy
+		}
+
+		selected = append(selected, scenario)
+	}
+
+	return selected, nil
+}
+
+// validate 检查所有通配符是否有效
+func (f *Filter) validate() error {
+	for _, patterns := range [][]string{f.Scenarios, f.Steps, f.Tags, f.ExcludeTags} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("无效的过滤条件 %q: %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// selectSteps 选出名称匹配的步骤，以及它们直接或间接依赖的步骤，保持原有顺序
+func selectSteps(steps []yaml.Step, patterns []string) []yaml.Step {
+	return selectStepsFunc(steps, func(name string) bool {
+		return matchAny(patterns, name)
+	})
+}
+
+// selectStepsFunc 选出 match 返回 true 的步骤及其依赖的步骤，保持原有顺序
+// 依赖的步骤包括 dependencies 中的步骤、if / unless 中 steps.<步骤名> 引用的步骤，
+// 以及提取了步骤所引用变量的前面的步骤（没有声明 dependencies 时变量通常通过 extract 传递）
+func selectStepsFunc(steps []yaml.Step, match func(name string) bool) []yaml.Step {
+	index := make(map[string]int, len(steps))
+	for i, step := range steps {
+		if _, exists := index[step.Name]; !exists {
+			index[step.Name] = i
+		}
+	}
+
+	keep := make([]bool, len(steps))
+	var include func(i int)
+	include = func(i int) {
+		if keep[i] {
+			return
+		}
+		keep[i] = true
+
+		variables, stepNames := stepReferences(&steps[i])
+		for _, dep := range append(steps[i].Dependencies, stepNames...) {
+			if j, exists := index[dep]; exists {
+				include(j)
+			}
+		}
+		for _, name := range variables {
+			if j := lastExtractor(steps[:i], name); j >= 0 {
+				include(j)
+			}
+		}
+	}
+
+	for i, step := range steps {
+		if match(step.Name) {
+			include(i)
+		}
+	}
+
+	var selected []yaml.Step
+	for i, step := range steps {
+		if keep[i] {
+			selected = append(selected, step)
+		}
+	}
+	return selected
+}
+
+// lastExtractor 返回最后一个提取了变量 name 的步骤（包括嵌套步骤）的下标，没有时返回 -1
+func lastExtractor(steps []yaml.Step, name string) int {
+	for i := len(steps) - 1; i >= 0; i-- {
+		for _, extracted := range extractedNames([]yaml.Step{steps[i]}) {
+			if extracted == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// stepReferences 返回步骤（包括嵌套步骤）引用的变量名，以及 if / unless 条件中 steps.<步骤名> 引用的步骤名
+func stepReferences(step *yaml.Step) (variables []string, stepNames []string) {
+	seen := make(map[string]bool)
+	addVariable := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			variables = append(variables, name)
+		}
+	}
+
+	var collect func(step *yaml.Step)
+	collect = func(step *yaml.Step) {
+		texts := []string{step.Endpoint, step.BaseURL, step.Foreach}
+		for _, values := range []map[string]string{step.PathParams, step.QueryParams, step.Headers, step.Extract} {
+			for _, value := range values {
+				texts = append(texts, value)
+			}
+		}
+		if step.Auth != nil {
+			auth := step.Auth
+			texts = append(texts, auth.Username, auth.Password, auth.Token, auth.Name, auth.Value, auth.TokenURL, auth.ClientID, auth.ClientSecret)
+			texts = append(texts, auth.Scopes...)
+		}
+		for path, expected := range step.Assert {
+			texts = append(texts, path)
+			texts = appendTexts(texts, expected)
+		}
+		texts = appendTexts(texts, step.RequestBody)
+		for _, value := range step.With {
+			texts = appendTexts(texts, value)
+		}
+
+		for _, text := range texts {
+			for _, action := range templateActionPattern.FindAllStringSubmatch(text, -1) {
+				for _, ref := range templateFieldPattern.FindAllStringSubmatch(action[1], -1) {
+					addVariable(ref[1])
+				}
+			}
+		}
+
+		// 端点中没有 path_params 取值的 {param} 使用同名变量
+		endpoint := templateActionPattern.ReplaceAllString(step.Endpoint, "")
+		for _, match := range bracePlaceholderPattern.FindAllStringSubmatch(endpoint, -1) {
+			if _, exists := step.PathParams[match[1]]; !exists {
+				addVariable(match[1])
+			}
+		}
+
+		// foreach 可以直接写变量路径，如 foreach: grants
+		if foreach := strings.TrimSpace(step.Foreach); foreach != "" && !strings.Contains(foreach, "{{") {
+			addVariable(variableRoot(foreach))
+		}
+
+		for _, condition := range []string{step.If, step.Unless, step.SkipIf} {
+			tokens, err := tokenizeExpression(condition)
+			if err != nil {
+				continue
+			}
+			for _, token := range tokens {
+				switch token.kind {
+				case tokenTemplate:
+					for _, ref := range templateFieldPattern.FindAllStringSubmatch(strings.Trim(token.text, "{}"), -1) {
+						addVariable(ref[1])
+					}
+				case tokenIdent:
+					segments := splitIdentifier(token.text)
+					switch {
+					case len(segments) >= 2 && segments[0] == "steps":
+						stepNames = append(stepNames, segments[1])
+					case len(segments) >= 2 && segments[0] == "vars":
+						addVariable(segments[1])
+					case token.text != "true" && token.text != "false" && token.text != "null" && token.text != "nil":
+						addVariable(segments[0])
+					}
+				}
+			}
+		}
+
+		for i := range step.Steps {
+			collect(&step.Steps[i])
+		}
+	}
+	collect(step)
+
+	return variables, stepNames
+}
+
+// appendTexts 将对象、数组或字符串中的所有字符串（包括对象的键）追加到 texts
+func appendTexts(texts []string, value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		texts = append(texts, v)
+	case map[string]interface{}:
+		for key, item := range v {
+			texts = appendTexts(append(texts, key), item)
+		}
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			texts = appendTexts(append(texts, fmt.Sprint(key)), item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			texts = appendTexts(texts, item)
+		}
+	}
+	return texts
+}
+
+// matchAny 判断名称是否匹配任意一个通配符
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyTag 判断任意一个标签是否匹配任意一个通配符
+func matchAnyTag(patterns []string, tags []string) bool {
+	for _, tag := range tags {
+		if matchAny(patterns, tag) {
+			return true
+		}
+	}
+	return false
+}
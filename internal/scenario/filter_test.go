@@ -0,0 +1,71 @@
+package scenario
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+)
+
+func TestSelectStepsIncludesExtractors(t *testing.T) {
+	steps := []yaml.Step{
+		{Name: "登录", Extract: map[string]string{"token": "$.token"}},
+		{Name: "创建用户", Extract: map[string]string{"user.id": "$.id"}},
+		{Name: "创建团队", Extract: map[string]string{"team_id": "$.id"}},
+		{Name: "查询用户", Endpoint: "/users/{{.user.id}}", Headers: map[string]string{"Authorization": "Bearer {{.token}}"}},
+		{Name: "查询团队", Endpoint: "/teams/{team_id}"},
+		{Name: "删除用户", Endpoint: "/users", RequestBody: map[string]interface{}{"ids": []interface{}{"{{.user.id}}"}}},
+		{Name: "清理", If: "steps.创建团队.passed && token != ''"},
+		{Name: "重新登录", Extract: map[string]string{"token": "$.token"}},
+		{Name: "退出", Headers: map[string]string{"Authorization": "Bearer {{.token}}"}, Dependencies: []string{"查询团队"}},
+	}
+
+	tests := []struct {
+		name   string
+		failed []string
+		want   []string
+	}{
+		{"template references", []string{"查询用户"}, []string{"登录", "创建用户", "查询用户"}},
+		{"endpoint placeholder", []string{"查询团队"}, []string{"创建团队", "查询团队"}},
+		{"request body", []string{"删除用户"}, []string{"创建用户", "删除用户"}},
+		{"condition references", []string{"清理"}, []string{"登录", "创建团队", "清理"}},
+		{"latest extractor and dependencies", []string{"退出"}, []string{"创建团队", "查询团队", "重新登录", "退出"}},
+		{"no references", []string{"登录"}, []string{"登录"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := selectStepsFunc(steps, func(name string) bool {
+				return matchResultName(tt.failed, name)
+			})
+			var names []string
+			for _, step := range selected {
+				names = append(names, step.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("selected %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectStepsIncludesNestedExtractors(t *testing.T) {
+	steps := []yaml.Step{
+		{Name: "准备", Foreach: "{{.ids}}", Steps: []yaml.Step{
+			{Name: "创建", Extract: map[string]string{"order_id": "$.id"}},
+		}},
+		{Name: "其他"},
+		{Name: "支付", Foreach: "orders", Steps: []yaml.Step{
+			{Name: "付款", Endpoint: "/orders/{{.order_id}}/pay"},
+		}},
+	}
+
+	selected := selectStepsFunc(steps, func(name string) bool { return name == "支付" })
+	var names []string
+	for _, step := range selected {
+		names = append(names, step.Name)
+	}
+	if want := []string{"准备", "支付"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("selected %v, want %v", names, want)
+	}
+}
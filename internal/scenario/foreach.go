@@ -0,0 +1,100 @@
+package scenario
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/types"
+)
+
+// defaultMaxIterations 循环步骤默认的最大循环次数
+const defaultMaxIterations = 100
+
+// runForeach 遍历 foreach 指定的数组变量，对每个元素运行嵌套步骤
+// 嵌套步骤的结果以 "循环步骤名[下标] 嵌套步骤名" 命名
+func (m *Manager) runForeach(scenario *yaml.Scenario, step *yaml.Step) []*types.EndpointTestResult {
+	items, err := m.resolveForeachItems(step.Foreach)
+	if err != nil {
+		return []*types.EndpointTestResult{m.failStep(scenario, step, err.Error())}
+	}
+
+	maxIterations := step.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = defaultMaxIterations
+	}
+	if len(items) > maxIterations {
+		fmt.Printf("警告: 循环步骤 %s 共有 %d 个元素，超过最大循环次数 %d，只处理前 %d 个\n",
+			step.Name, len(items), maxIterations, maxIterations)
+		items = items[:maxIterations]
+	}
+
+	fmt.Printf("执行循环步骤: %s (%d 个元素)\n", step.Name, len(items))
+
+	var results []*types.EndpointTestResult
+	for index, item := range items {
+		// 每次循环重新计算嵌套步骤的依赖状态
+		for _, nested := range step.Steps {
+			m.Context.ClearStepStatus(nested.Name)
+		}
+
+		// item 和 index 只在本次循环的嵌套步骤中可见，不影响并行执行的其他步骤
+		iterationResults := m.withLocals(map[string]interface{}{
+			"item":  item,
+			"index": index,
+		}).runSteps(scenario, step.Steps)
+
+		for _, result := range iterationResults {
+			result.Step = fmt.Sprintf("%s[%d] %s", step.Name, index, result.Step)
+		}
+		results = append(results, iterationResults...)
+	}
+
+	return results
+}
+
+// resolveForeachItems 解析 foreach 表达式，返回要遍历的数组
+// 支持 "{{.grants}}"、"{{.data.items}}" 以及直接写变量路径 "grants"
+func (m *Manager) resolveForeachItems(expression string) ([]interface{}, error) {
+	path := strings.TrimSpace(expression)
+	if match := templateVarPattern.FindStringSubmatch(path); match != nil {
+		path = match[1]
+	}
+
+	value, ok := m.lookupVariable(path)
+	if !ok {
+		return nil, fmt.Errorf("foreach 变量 %s 不存在", path)
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		return v, nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("foreach 变量 %s 不是数组，实际类型为 %T", path, value)
+	}
+}
+
+// failedResult 创建未能执行的步骤的失败结果
+func (m *Manager) failedResult(scenario *yaml.Scenario, step *yaml.Step, reason string) *types.EndpointTestResult {
+	return &types.EndpointTestResult{
+		Scenario: scenario.Name,
+		Step:     step.Name,
+		Endpoint: newStepEndpoint(step),
+		Validation: &types.ValidationResult{
+			Passed:        false,
+			FailureReason: reason,
+		},
+		TestTime: time.Now(),
+	}
+}
+
+// withLocals 创建运行嵌套步骤使用的管理器副本，locals 只对嵌套步骤可见，
+// 嵌套步骤提取的变量、结果和步骤状态仍然写入当前场景的上下文
+func (m *Manager) withLocals(locals map[string]interface{}) *Manager {
+	scoped := *m
+	scoped.Context = m.Context.withLocals(locals)
+	return &scoped
+}
@@ -0,0 +1,72 @@
+package scenario
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+)
+
+func TestForeachLocalsDoNotLeak(t *testing.T) {
+	server := newRecordingServer(t, map[string]string{"/items": `{"ids": ["a", "b"]}`})
+
+	items := getStep("列表", "/items", nil)
+	items.Extract = map[string]string{"ids": "$.ids"}
+
+	loop := yaml.Step{Name: "循环", Foreach: "{{.ids}}", Steps: []yaml.Step{
+		getStep("查看", "/item", map[string]string{"item": "{{.item}}", "index": "{{.index}}"}),
+	}}
+
+	config := &yaml.Config{Scenarios: []yaml.Scenario{{
+		Name: "循环",
+		Steps: []yaml.Step{
+			items,
+			loop,
+			getStep("之后", "/after", map[string]string{"item": "{{.item}}", "index": "{{.index}}"}),
+		},
+	}}}
+
+	runTestScenarios(t, server, config)
+
+	if got, want := server.query("/item", "item"), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/item item = %v, want %v", got, want)
+	}
+	if got, want := server.query("/item", "index"), []string{"0", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/item index = %v, want %v", got, want)
+	}
+	if got, want := server.query("/after", "item"), []string{"{{.item}}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/after item = %v, want %v", got, want)
+	}
+	if got, want := server.query("/after", "index"), []string{"{{.index}}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/after index = %v, want %v", got, want)
+	}
+}
+
+func TestParallelForeachKeepsItemsSeparate(t *testing.T) {
+	server := newRecordingServer(t, nil)
+
+	config := &yaml.Config{
+		Variables: map[string]interface{}{
+			"letters": []interface{}{"a", "b", "c", "d"},
+			"numbers": []interface{}{"1", "2", "3", "4"},
+		},
+		Scenarios: []yaml.Scenario{{
+			Name:     "并行循环",
+			Parallel: 2,
+			Steps: []yaml.Step{
+				{Name: "字母", Foreach: "letters", Steps: []yaml.Step{getStep("字母请求", "/letters/{{.item}}", nil)}},
+				{Name: "数字", Foreach: "numbers", Steps: []yaml.Step{getStep("数字请求", "/numbers/{{.item}}", nil)}},
+			},
+		}},
+	}
+
+	for i := 0; i < 10; i++ {
+		server.reset()
+		runTestScenarios(t, server, config)
+
+		want := []string{"/letters/a", "/letters/b", "/letters/c", "/letters/d", "/numbers/1", "/numbers/2", "/numbers/3", "/numbers/4"}
+		if got := server.paths(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("paths = %v, want %v", got, want)
+		}
+	}
+}
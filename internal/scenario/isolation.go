@@ -0,0 +1,87 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/types"
+)
+
+// runScenarios 运行一组场景，每个场景使用独立的上下文
+// Parallel 大于 1 时并发运行场景，结果仍按场景在配置中的顺序返回
+func (m *Manager) runScenarios(scenarios []*yaml.Scenario) ([]*types.EndpointTestResult, error) {
+	scenarioResults := make([][]*types.EndpointTestResult, len(scenarios))
+	errs := make([]error, len(scenarios))
+
+	if m.Parallel > 1 {
+		fmt.Printf("并发运行 %d 个场景，最大并发数 %d\n", len(scenarios), m.Parallel)
+
+		semaphore := make(chan struct{}, m.Parallel)
+		var wg sync.WaitGroup
+		for i, scenario := range scenarios {
+			wg.Add(1)
+			go func(i int, scenario *yaml.Scenario) {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+
+				scenarioResults[i], errs[i] = m.forScenario().runIsolated(scenario)
+			}(i, scenario)
+		}
+		wg.Wait()
+	} else {
+		for i, scenario := range scenarios {
+			scenarioResults[i], errs[i] = m.forScenario().runIsolated(scenario)
+			if errs[i] != nil {
+				break
+			}
+		}
+	}
+
+	var results []*types.EndpointTestResult
+	for i := range scenarios {
+		if errs[i] != nil {
+			return results, errs[i]
+		}
+		results = append(results, scenarioResults[i]...)
+	}
+
+	return results, nil
+}
+
+// runIsolated 在场景自己的上下文中运行场景，运行停止后不再开始新的场景
+// 配置了 timeout 的场景超时后停止执行剩余步骤
+func (m *Manager) runIsolated(scenario *yaml.Scenario) ([]*types.EndpointTestResult, error) {
+	if reason := m.runStopReason(); reason != "" {
+		return m.skipSteps(scenario, scenario.Steps, notRunPrefix+reason), nil
+	}
+
+	timeout, err := parseDuration(scenario.Timeout, 0)
+	if err != nil {
+		return nil, fmt.Errorf("场景 %s 的超时时间无效: %v", scenario.Name, err)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		m.ctx, cancel = context.WithTimeoutCause(m.ctx, timeout, fmt.Errorf("场景超过超时时间 %v", timeout))
+		defer cancel()
+	}
+
+	return m.runScenario(scenario)
+}
+
+// forScenario 创建运行单个场景使用的管理器副本
+// 副本的上下文以当前变量（全局变量和 before_all 提取的变量）为初始值，
+// 场景中提取的变量和步骤状态不会影响其他场景
+func (m *Manager) forScenario() *Manager {
+	scoped := *m
+	scoped.Context = &Context{
+		Variables:  m.Context.VariablesSnapshot(),
+		Results:    make(map[string]*types.EndpointTestResult),
+		StepStatus: make(map[string]bool),
+		Extracted:  make(map[string]interface{}),
+	}
+	scoped.scenarioStop = &stopState{}
+	return &scoped
+}
@@ -0,0 +1,92 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/types"
+)
+
+// runSuite 运行一组场景：先执行 before_all，再依次运行场景，最后总是执行 after_all
+func (m *Manager) runSuite(scenarios []*yaml.Scenario) ([]*types.EndpointTestResult, error) {
+	var allResults []*types.EndpointTestResult
+
+	// 运行 before_all，失败时跳过所有场景
+	beforeAllPassed := true
+	if m.Config != nil && len(m.Config.BeforeAll) > 0 {
+		results := m.runPhase(&yaml.Scenario{Name: "before_all"}, "before_all", m.Config.BeforeAll)
+		allResults = append(allResults, results...)
+		beforeAllPassed = allPassed(results)
+	}
+
+	var runErr error
+	if beforeAllPassed {
+		var results []*types.EndpointTestResult
+		results, runErr = m.runScenarios(scenarios)
+		allResults = append(allResults, results...)
+	} else {
+		for _, scenario := range scenarios {
+			allResults = append(allResults, m.skipSteps(scenario, scenario.Steps, "before_all 失败")...)
+		}
+	}
+
+	// 无论场景是否失败或运行是否被中断，都运行 after_all
+	if m.Config != nil && len(m.Config.AfterAll) > 0 {
+		allResults = append(allResults, m.runCleanup(&yaml.Scenario{Name: "after_all"}, "after_all", m.Config.AfterAll)...)
+	}
+
+	if runErr != nil {
+		return nil, runErr
+	}
+
+	return allResults, nil
+}
+
+// runPhase 运行 setup、teardown 等阶段的步骤，结果的步骤名带上阶段前缀
+func (m *Manager) runPhase(scenario *yaml.Scenario, phase string, steps []yaml.Step) []*types.EndpointTestResult {
+	fmt.Printf("运行 %s 步骤: %s\n", phase, scenario.Name)
+
+	results := m.runSteps(scenario, steps)
+	for _, result := range results {
+		result.Step = fmt.Sprintf("[%s] %s", phase, result.Step)
+	}
+
+	return results
+}
+
+// runCleanup 运行清理阶段的步骤，清理阶段不受中断、截止时间和场景超时影响
+// 请求仍使用各自的超时时间
+func (m *Manager) runCleanup(scenario *yaml.Scenario, phase string, steps []yaml.Step) []*types.EndpointTestResult {
+	ctx := m.ctx
+	m.cleanup = true
+	m.ctx = context.WithoutCancel(ctx)
+	defer func() {
+		m.cleanup = false
+		m.ctx = ctx
+	}()
+
+	return m.runPhase(scenario, phase, steps)
+}
+
+// skipSteps 将一组步骤全部标记为跳过
+func (m *Manager) skipSteps(scenario *yaml.Scenario, steps []yaml.Step, reason string) []*types.EndpointTestResult {
+	results := make([]*types.EndpointTestResult, 0, len(steps))
+	for i := range steps {
+		fmt.Printf("跳过步骤 %s，%s\n", steps[i].Name, reason)
+		result := m.skippedResult(scenario, &steps[i], reason)
+		m.Context.SetResult(steps[i].Name, result)
+		results = append(results, result)
+	}
+	return results
+}
+
+// allPassed 判断一组结果是否全部通过（跳过的步骤不算失败）
+func allPassed(results []*types.EndpointTestResult) bool {
+	for _, result := range results {
+		if !result.Validation.Skipped && !result.Validation.Passed {
+			return false
+		}
+	}
+	return true
+}
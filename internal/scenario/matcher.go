@@ -0,0 +1,327 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// matcherOperators 结构化断言支持的操作符
+// 例如：$.items: {length: ">=1"}、$.id: {matches: "^usr_"}、$.tags: {contains: "vip"}
+var matcherOperators = map[string]bool{
+	"eq":       true,
+	"exists":   true,
+	"length":   true,
+	"matches":  true,
+	"type":     true,
+	"in":       true,
+	"contains": true,
+	"between":  true,
+	"not":      true,
+}
+
+// matcherTypes type 操作符支持的类型名称
+var matcherTypes = map[string]bool{
+	"string":    true,
+	"number":    true,
+	"integer":   true,
+	"boolean":   true,
+	"array":     true,
+	"object":    true,
+	"null":      true,
+	"undefined": true,
+}
+
+// isMatcher 判断期望值是否为结构化断言，返回去掉 $ 前缀的操作符
+// 操作符带 $ 前缀（如 {$type: string}）时总是结构化断言；
+// 不带前缀时所有键都必须是操作符且取值符合操作符的要求，
+// 因此 {type: admin}、{in: 1} 这样的普通对象仍按对象相等比较
+func isMatcher(expectedValue interface{}) (map[string]interface{}, bool) {
+	object, ok := expectedValue.(map[string]interface{})
+	if !ok || len(object) == 0 {
+		return nil, false
+	}
+
+	matcher := make(map[string]interface{}, len(object))
+	explicit := false
+	for key, value := range object {
+		op := strings.TrimPrefix(key, "$")
+		if !matcherOperators[op] {
+			return nil, false
+		}
+		if op != key {
+			explicit = true
+		}
+		matcher[op] = value
+	}
+	if len(matcher) != len(object) {
+		// 同时写了 type 和 $type
+		return nil, false
+	}
+	if explicit {
+		return matcher, true
+	}
+
+	for op, value := range matcher {
+		if !validOperand(op, value) {
+			return nil, false
+		}
+	}
+	return matcher, true
+}
+
+// validOperand 判断不带 $ 前缀的操作符的取值是否符合该操作符的要求
+func validOperand(op string, value interface{}) bool {
+	switch op {
+	case "exists":
+		_, ok := value.(bool)
+		return ok
+	case "length":
+		switch v := value.(type) {
+		case int, int64, float64:
+			return true
+		case string:
+			return strings.HasPrefix(v, ">") || strings.HasPrefix(v, "<") || isNumeric(v)
+		}
+		return false
+	case "matches":
+		pattern, ok := value.(string)
+		return ok && pattern != ""
+	case "type":
+		name, ok := value.(string)
+		return ok && matcherTypes[strings.ToLower(name)]
+	case "in":
+		_, ok := value.([]interface{})
+		return ok
+	case "between":
+		bounds, ok := value.([]interface{})
+		if !ok || len(bounds) != 2 {
+			return false
+		}
+		_, minOK := toFloat(bounds[0])
+		_, maxOK := toFloat(bounds[1])
+		return minOK && maxOK
+	default:
+		// eq、contains、not 可以是任意值
+		return true
+	}
+}
+
+// isNumeric 判断字符串是否为数字
+func isNumeric(value string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return err == nil
+}
+
+// expectsAbsent 判断结构化断言是否期望值不存在（{exists: false}）
+func expectsAbsent(expectedValue interface{}) bool {
+	matcher, ok := isMatcher(expectedValue)
+	if !ok {
+		return false
+	}
+	exists, ok := matcher["exists"].(bool)
+	return ok && !exists
+}
+
+// compareMatcher 按结构化断言比较实际值，所有操作符都满足才算通过
+func (m *Manager) compareMatcher(label string, result gjson.Result, matcher map[string]interface{}) (bool, string) {
+	// 按操作符名称排序，保证错误信息稳定
+	for _, op := range sortedKeys(matcher) {
+		if passed, reason := m.compareOperator(label, result, op, matcher[op]); !passed {
+			return false, reason
+		}
+	}
+
+	return true, ""
+}
+
+// compareOperator 执行单个结构化断言操作符
+func (m *Manager) compareOperator(label string, result gjson.Result, op string, expected interface{}) (bool, string) {
+	actualDisplay := truncateDisplay(result.Raw)
+
+	switch op {
+	case "eq":
+		// 对象按字面比较，{eq: {type: string}} 比较的是对象本身而不是结构化断言
+		if object, ok := expected.(map[string]interface{}); ok {
+			return compareJSON(label, result, object)
+		}
+		return m.compareResult(label, result, expected)
+
+	case "exists":
+		want, ok := expected.(bool)
+		if !ok {
+			return false, fmt.Sprintf("%s: exists 的期望值必须是布尔值，实际为 %v", label, expected)
+		}
+		if result.Exists() == want {
+			return true, ""
+		}
+		if want {
+			return false, fmt.Sprintf("%s 不存在于响应中", label)
+		}
+		return false, fmt.Sprintf("%s: 期望不存在，实际值 %s", label, actualDisplay)
+
+	case "length":
+		length, ok := resultLength(result)
+		if !ok {
+			return false, fmt.Sprintf("%s: 实际值 %s 的类型 %s 没有长度", label, actualDisplay, resultType(result))
+		}
+		return m.compareResult(label+" 的长度", numberResult(float64(length)), expected)
+
+	case "matches":
+		pattern := fmt.Sprintf("%v", expected)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Sprintf("%s: 无效的正则表达式 %s: %v", label, pattern, err)
+		}
+		if re.MatchString(result.String()) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s: 实际值 \"%s\" 不匹配正则 %s", label, truncateDisplay(result.String()), pattern)
+
+	case "type":
+		want := strings.ToLower(fmt.Sprintf("%v", expected))
+		actual := resultType(result)
+		if actual == want || (want == "number" && actual == "integer") {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s: 实际类型 %s 不是期望类型 %s", label, actual, want)
+
+	case "in":
+		candidates, ok := expected.([]interface{})
+		if !ok {
+			return false, fmt.Sprintf("%s: in 的期望值必须是数组，实际为 %v", label, expected)
+		}
+		for _, candidate := range candidates {
+			if passed, _ := m.compareResult(label, result, candidate); passed {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("%s: 实际值 %s 不在 %s 中", label, actualDisplay, displayJSON(candidates))
+
+	case "contains":
+		if result.IsArray() {
+			for _, item := range result.Array() {
+				if passed, _ := m.compareResult(label, item, expected); passed {
+					return true, ""
+				}
+			}
+			return false, fmt.Sprintf("%s: 数组 %s 不包含元素 %s", label, actualDisplay, displayJSON(expected))
+		}
+		if result.IsObject() {
+			key := fmt.Sprintf("%v", expected)
+			if result.Get(gjson.Escape(key)).Exists() {
+				return true, ""
+			}
+			return false, fmt.Sprintf("%s: 对象 %s 不包含键 %s", label, actualDisplay, key)
+		}
+		substr := fmt.Sprintf("%v", expected)
+		if strings.Contains(result.String(), substr) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s: 实际值 \"%s\" 不包含 \"%s\"", label, truncateDisplay(result.String()), substr)
+
+	case "between":
+		bounds, ok := expected.([]interface{})
+		if !ok || len(bounds) != 2 {
+			return false, fmt.Sprintf("%s: between 的期望值必须是 [最小值, 最大值]，实际为 %v", label, expected)
+		}
+		min, minOK := toFloat(bounds[0])
+		max, maxOK := toFloat(bounds[1])
+		if !minOK || !maxOK {
+			return false, fmt.Sprintf("%s: between 的边界必须是数字，实际为 %v", label, expected)
+		}
+		val := result.Float()
+		if val >= min && val <= max {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s: 实际值 %v 不在区间 [%v, %v] 内", label, val, min, max)
+
+	case "not":
+		if passed, _ := m.compareResult(label, result, expected); passed {
+			return false, fmt.Sprintf("%s: 实际值 %s 满足了 not 条件 %s", label, actualDisplay, displayJSON(expected))
+		}
+		return true, ""
+
+	default:
+		return false, fmt.Sprintf("%s: 不支持的断言操作符 %s", label, op)
+	}
+}
+
+// toFloat 将 YAML 解析出的数字或数字字符串转换为 float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// resultLength 获取数组、对象或字符串的长度
+func resultLength(result gjson.Result) (int, bool) {
+	switch {
+	case result.IsArray():
+		return len(result.Array()), true
+	case result.IsObject():
+		return len(result.Map()), true
+	case result.Type == gjson.String:
+		return utf8.RuneCountInString(result.Str), true
+	default:
+		return 0, false
+	}
+}
+
+// resultType 获取实际值的类型名称
+func resultType(result gjson.Result) string {
+	switch {
+	case !result.Exists():
+		return "undefined"
+	case result.IsArray():
+		return "array"
+	case result.IsObject():
+		return "object"
+	}
+
+	switch result.Type {
+	case gjson.Null:
+		return "null"
+	case gjson.True, gjson.False:
+		return "boolean"
+	case gjson.Number:
+		if result.Num == float64(int64(result.Num)) && !strings.ContainsAny(result.Raw, ".eE") {
+			return "integer"
+		}
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// displayJSON 将期望值格式化为便于阅读的 JSON 字符串
+func displayJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return truncateDisplay(string(data))
+}
+
+// truncateDisplay 截断过长的显示内容
+func truncateDisplay(value string) string {
+	if len(value) > 100 {
+		return value[:100] + "..."
+	}
+	return value
+}
@@ -0,0 +1,54 @@
+package scenario
+
+import (
+	"testing"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/parser"
+	"github.com/tidwall/gjson"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func TestCompareResultMatchers(t *testing.T) {
+	m := NewManager(nil, &parser.APIDefinition{}, nil, &yaml.Config{})
+
+	tests := []struct {
+		name     string
+		actual   string
+		expected string
+		want     bool
+	}{
+		// 键与操作符同名的普通对象仍按对象相等比较
+		{"plain object with type key", `{"type":"admin"}`, `{type: admin}`, true},
+		{"plain object with type key mismatch", `{"type":"user"}`, `{type: admin}`, false},
+		{"plain object with in key", `{"in":"header"}`, `{in: header}`, true},
+		{"plain object with exists key", `{"exists":"yes"}`, `{exists: "yes"}`, true},
+		{"plain object with between key", `{"between":"a"}`, `{between: a}`, true},
+		{"eq forces object equality", `{"type":"string"}`, `{eq: {type: string}}`, true},
+
+		// 取值符合操作符要求时按结构化断言处理
+		{"type matcher", `"abc"`, `{type: string}`, true},
+		{"type matcher mismatch", `123`, `{type: string}`, false},
+		{"length matcher", `[1,2,3]`, `{length: ">=1", type: array}`, true},
+		{"in matcher", `"paid"`, `{in: [paid, refunded]}`, true},
+		{"between matcher", `5`, `{between: [1, 10]}`, true},
+
+		// $ 前缀的操作符总是结构化断言
+		{"explicit type matcher", `"abc"`, `{$type: string}`, true},
+		{"explicit contains matcher", `"hello world"`, `{$contains: world}`, true},
+		{"explicit matcher with invalid operand", `{"type":"admin"}`, `{$type: admin}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var expected interface{}
+			if err := yamlv3.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatalf("invalid expectation %s: %v", tt.expected, err)
+			}
+			passed, reason := m.compareResult("$.value", gjson.Parse(tt.actual), expected)
+			if passed != tt.want {
+				t.Errorf("compareResult(%s, %s) = %v (%s), want %v", tt.actual, tt.expected, passed, reason, tt.want)
+			}
+		})
+	}
+}
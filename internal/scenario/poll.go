@@ -0,0 +1,159 @@
+package scenario
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/parser"
+	"github.com/gaoyong06/api-tester/internal/types"
+	"github.com/gaoyong06/api-tester/pkg/client"
+)
+
+const (
+	// defaultPollInterval 默认轮询间隔
+	defaultPollInterval = time.Second
+	// defaultPollMaxAttempts 默认最大轮询次数
+	defaultPollMaxAttempts = 10
+	// defaultPollMaxInterval 未配置 max_interval 时退避的最大间隔（轮询间隔更大时使用轮询间隔）
+	defaultPollMaxInterval = 30 * time.Second
+)
+
+// sendWithPolling 发送请求；如果步骤配置了 retry，则重复发送直到满足 until 条件或用完尝试次数
+// 每次请求都按 retries 自动重试网络错误和 retry_on 状态码
+// 返回最后一次响应、每次尝试的记录以及自动重试的总次数
+func (m *Manager) sendWithPolling(scenario *yaml.Scenario, step *yaml.Step, endpoint *parser.Endpoint, pathParams, queryParams map[string]string, options *client.RequestOptions) (*client.Response, []types.AttemptResult, int, error) {
+	policy := m.retryPolicy(scenario, step)
+
+	poll := step.Retry
+	if poll == nil {
+		response, retried, err := m.sendWithRetry(policy, endpoint, pathParams, queryParams, options)
+		if err != nil || len(retried) == 0 {
+			return response, retried, len(retried), err
+		}
+
+		// 重试过的请求记录每次尝试，最后一次为最终结果
+		reason := policy.retryReason(endpoint.Method, response)
+		if response.Error != nil {
+			reason = response.Error.Error()
+		}
+		attempts := append(retried, types.AttemptResult{
+			Attempt:       len(retried) + 1,
+			StatusCode:    response.StatusCode,
+			ResponseTime:  response.ResponseTime,
+			Passed:        reason == "",
+			FailureReason: reason,
+			Time:          time.Now(),
+		})
+		return response, attempts, len(retried), nil
+	}
+
+	// 停止条件默认使用步骤断言
+	until := poll.Until
+	if len(until) == 0 {
+		until = step.Assert
+	}
+
+	interval, err := parseDuration(poll.Interval, defaultPollInterval)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("无效的轮询间隔: %v", err)
+	}
+	maxInterval, err := parseDuration(poll.MaxInterval, 0)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("无效的最大轮询间隔: %v", err)
+	}
+	maxAttempts := poll.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultPollMaxAttempts
+	}
+
+	var attempts []types.AttemptResult
+	var response *client.Response
+	retries := 0
+
+	for attempt := 1; ; attempt++ {
+		var retried []types.AttemptResult
+		response, retried, err = m.sendWithRetry(policy, endpoint, pathParams, queryParams, options)
+		retries += len(retried)
+		if err != nil {
+			return nil, attempts, retries, err
+		}
+
+		passed, reason, _ := m.validateResponse(until, response)
+		if response.Error != nil {
+			passed, reason = false, response.Error.Error()
+		}
+		attempts = append(attempts, types.AttemptResult{
+			Attempt:       attempt,
+			StatusCode:    response.StatusCode,
+			ResponseTime:  response.ResponseTime,
+			Passed:        passed,
+			FailureReason: reason,
+			Time:          time.Now(),
+		})
+
+		if passed {
+			fmt.Printf("轮询完成: 第 %d 次尝试满足条件\n", attempt)
+			break
+		}
+		if attempt >= maxAttempts || m.stopReason() != "" {
+			fmt.Printf("轮询结束: %d 次尝试后仍未满足条件\n", attempt)
+			break
+		}
+
+		delay := backoffDelay(poll.Backoff, interval, attempt, maxInterval)
+		fmt.Printf("第 %d 次尝试未满足条件 (%s)，%v 后重试\n", attempt, strings.ReplaceAll(reason, "\n", "; "), delay)
+		m.sleep(delay)
+	}
+
+	return response, attempts, retries, nil
+}
+
+// backoffDelay 计算第 attempt 次尝试后的等待时间，不超过 maxInterval
+// maxInterval 为 0 时使用 defaultPollMaxInterval；达到上限后不再增加，避免尝试次数多时溢出
+func backoffDelay(strategy string, interval time.Duration, attempt int, maxInterval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	if maxInterval <= 0 {
+		maxInterval = defaultPollMaxInterval
+		if interval > maxInterval {
+			maxInterval = interval
+		}
+	}
+
+	delay := interval
+	switch strings.ToLower(strategy) {
+	case "exponential":
+		for i := 1; i < attempt && delay < maxInterval; i++ {
+			delay *= 2
+		}
+	case "linear":
+		if attempt > 1 {
+			if time.Duration(attempt) > maxInterval/interval {
+				delay = maxInterval
+			} else {
+				delay = interval * time.Duration(attempt)
+			}
+		}
+	}
+
+	if delay > maxInterval {
+		delay = maxInterval
+	}
+	return delay
+}
+
+// parseDuration 解析时间间隔配置，纯数字表示秒，空字符串时返回默认值
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultValue, nil
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(value)
+}
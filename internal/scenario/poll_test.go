@@ -0,0 +1,49 @@
+package scenario
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategy    string
+		interval    time.Duration
+		attempt     int
+		maxInterval time.Duration
+		want        time.Duration
+	}{
+		{"constant", "constant", time.Second, 5, 0, time.Second},
+		{"exponential grows", "exponential", time.Second, 4, 0, 8 * time.Second},
+		{"exponential default cap", "exponential", time.Second, 20, 0, defaultPollMaxInterval},
+		{"exponential explicit cap", "exponential", time.Second, 20, 10 * time.Second, 10 * time.Second},
+		{"exponential no overflow", "exponential", time.Second, 100, 0, defaultPollMaxInterval},
+		{"exponential huge attempt", "exponential", time.Millisecond, 1 << 20, time.Hour, time.Hour},
+		{"interval above default cap", "exponential", time.Minute, 5, 0, time.Minute},
+		{"linear", "linear", time.Second, 3, 0, 3 * time.Second},
+		{"linear default cap", "linear", time.Second, 1000, 0, defaultPollMaxInterval},
+		{"linear no overflow", "linear", time.Hour, 1 << 40, 2 * time.Hour, 2 * time.Hour},
+		{"zero interval", "exponential", 0, 10, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := backoffDelay(tt.strategy, tt.interval, tt.attempt, tt.maxInterval)
+			if got != tt.want {
+				t.Errorf("backoffDelay(%q, %v, %d, %v) = %v, want %v", tt.strategy, tt.interval, tt.attempt, tt.maxInterval, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffDelayMonotonic(t *testing.T) {
+	previous := time.Duration(0)
+	for attempt := 1; attempt <= 100; attempt++ {
+		delay := backoffDelay("exponential", time.Second, attempt, 0)
+		if delay <= 0 || delay < previous {
+			t.Fatalf("attempt %d: delay %v after %v", attempt, delay, previous)
+		}
+		previous = delay
+	}
+}
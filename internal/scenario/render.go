@@ -0,0 +1,220 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gaoyong06/api-tester/internal/template"
+	"github.com/gaoyong06/api-tester/pkg/utils"
+)
+
+// templates 渲染步骤模板的处理器，只读使用，可以在并行运行的场景之间共享
+var templates = template.NewProcessor()
+
+// variableRefPattern 匹配只引用单个变量的模板动作，如 {{.user_id}}、{{ .items.0.id }}
+var variableRefPattern = regexp.MustCompile(`{{\s*\.([a-zA-Z0-9_.]+)\s*}}`)
+
+// bracePlaceholderPattern 匹配 {name} 形式的占位符
+var bracePlaceholderPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// render 渲染步骤中的字符串：先用模板处理器渲染 {{...}} 模板，再替换 {name} 形式的占位符
+func (m *Manager) render(input string) string {
+	return m.replacePlaceholders(m.renderTemplate(input))
+}
+
+// renderTemplate 使用模板处理器渲染字符串中的 {{...}} 模板，数据为当前上下文变量
+// 支持 {{.var}}、{{.item.id}}、{{.items.0}} 以及 {{uuid}}、{{randomString 8}}、{{env "NAME"}} 等内置函数
+// 单个变量的引用按 resolveVariable 查找，找不到时保留原占位符；模板渲染失败时返回原字符串
+func (m *Manager) renderTemplate(input string) string {
+	if !strings.Contains(input, "{{") {
+		return input
+	}
+
+	// 单个变量的引用改写为 index 调用，由 resolveVariable 负责查找
+	// 这样点号路径中可以使用数组下标，找不到的变量也能原样保留
+	data := m.Context.VariablesSnapshot()
+	source := variableRefPattern.ReplaceAllStringFunc(input, func(placeholder string) string {
+		path := variableRefPattern.FindStringSubmatch(placeholder)[1]
+		value, ok := m.resolveVariable(path)
+		if !ok {
+			fmt.Printf("  警告: 无法替换变量 %s，将保持原样\n", placeholder)
+			m.recordProblem(fmt.Sprintf("变量 %s 不存在", placeholder))
+			value = placeholder
+		}
+		data[path] = value
+		return fmt.Sprintf("{{index . %q}}", path)
+	})
+
+	rendered, err := templates.Render(source, data)
+	if err != nil {
+		fmt.Printf("  警告: 渲染模板 %s 失败，将保持原样: %v\n", masked(input), err)
+		m.recordProblem(fmt.Sprintf("渲染模板 %s 失败: %v", input, err))
+		return input
+	}
+	return rendered
+}
+
+// renderValue 渲染请求体、模板参数等值中所有的字符串，保持对象和数组的结构不变
+// 只包含单个变量引用的字符串（如 "{{.amount}}"）保留变量的原始类型（数字、布尔、对象、数组），
+// 其他字符串渲染为字符串，序列化为 JSON 时会被正确转义
+func (m *Manager) renderValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if match := templateVarPattern.FindStringSubmatch(strings.TrimSpace(v)); match != nil {
+			if resolved, ok := m.resolveVariable(match[1]); ok {
+				return resolved
+			}
+		}
+		return m.render(v)
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered[m.render(key)] = m.renderValue(item)
+		}
+		return rendered
+	case map[interface{}]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered[m.render(fmt.Sprint(key))] = m.renderValue(item)
+		}
+		return rendered
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered[i] = m.renderValue(item)
+		}
+		return rendered
+	default:
+		return value
+	}
+}
+
+// renderBody 渲染请求体，返回要发送的请求体字符串
+// 对象和数组在解析后的结构上渲染并序列化为 JSON；字符串请求体是 JSON 对象或数组时同样解析后渲染，
+// 其他字符串请求体按文本渲染
+func (m *Manager) renderBody(body interface{}) (string, error) {
+	if text, ok := body.(string); ok {
+		trimmed := strings.TrimSpace(text)
+		if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+			return m.render(text), nil
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(trimmed))
+		decoder.UseNumber()
+		var parsed interface{}
+		if err := decoder.Decode(&parsed); err != nil || decoder.More() {
+			return m.render(text), nil
+		}
+		body = parsed
+	}
+
+	jsonBytes, err := json.Marshal(m.renderValue(body))
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+// replacePlaceholders 替换 {name} 形式的占位符，找不到变量的占位符保持不变
+func (m *Manager) replacePlaceholders(input string) string {
+	return bracePlaceholderPattern.ReplaceAllStringFunc(input, func(placeholder string) string {
+		name := bracePlaceholderPattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := m.resolveVariable(name); ok {
+			return fmt.Sprintf("%v", value)
+		}
+		return placeholder
+	})
+}
+
+// resolveVariable 查找变量：先按点号路径精确查找上下文变量，
+// 开启 fuzzy_variables 时再尝试相似名称，最后使用 default_values 中的默认值
+func (m *Manager) resolveVariable(path string) (interface{}, bool) {
+	if value, ok := m.lookupVariable(path); ok {
+		return value, true
+	}
+
+	if m.fuzzyVariables() {
+		if value, name, ok := m.lookupSimilarVariable(path); ok {
+			fmt.Printf("  变量 %s 不存在，使用相似名称变量 %s 的值: %s\n", path, name, masked(value))
+			return value, true
+		}
+	}
+
+	if value, ok := m.getDefaultValues()[path]; ok {
+		fmt.Printf("  变量 %s 不存在，使用默认值: %s\n", path, masked(value))
+		return value, true
+	}
+
+	return nil, false
+}
+
+// strict 判断是否开启了严格模式
+func (m *Manager) strict() bool {
+	return m.Config != nil && m.Config.Strict
+}
+
+// forStep 创建执行单个步骤使用的管理器副本，用于记录该步骤中的变量问题
+func (m *Manager) forStep() *Manager {
+	scoped := *m
+	scoped.problems = &[]string{}
+	return &scoped
+}
+
+// recordProblem 记录当前步骤中的变量问题，不在步骤中（如计算 if 条件）时忽略
+func (m *Manager) recordProblem(problem string) {
+	if m.problems != nil {
+		*m.problems = append(*m.problems, problem)
+	}
+}
+
+// strictFailure 严格模式下返回当前步骤变量问题的汇总，没有问题或不是严格模式时返回空字符串
+// 返回后清空已记录的问题
+func (m *Manager) strictFailure() string {
+	if !m.strict() || m.problems == nil || len(*m.problems) == 0 {
+		return ""
+	}
+	failure := "严格模式: " + strings.Join(*m.problems, "; ")
+	*m.problems = nil
+	return failure
+}
+
+// fuzzyVariables 判断是否开启了相似名称查找
+func (m *Manager) fuzzyVariables() bool {
+	return m.Config != nil && m.Config.FuzzyVariables
+}
+
+// lookupSimilarVariable 按相似名称查找变量：路径的第一段依次尝试驼峰形式、下划线形式和不区分大小写的匹配
+// 返回变量值和实际使用的路径
+func (m *Manager) lookupSimilarVariable(path string) (interface{}, string, bool) {
+	root, rest, _ := strings.Cut(path, ".")
+	suffix := ""
+	if rest != "" {
+		suffix = "." + rest
+	}
+
+	for _, name := range []string{toCamelCase(root), toSnakeCase(root)} {
+		if name == root {
+			continue
+		}
+		if value, ok := m.lookupVariable(name + suffix); ok {
+			return value, name + suffix, true
+		}
+	}
+
+	for name := range m.Context.VariablesSnapshot() {
+		if name != root && strings.EqualFold(name, root) {
+			if value, ok := m.lookupVariable(name + suffix); ok {
+				return value, name + suffix, true
+			}
+		}
+	}
+
+	return nil, "", false
+}
+
+// masked 将值格式化为字符串并屏蔽其中的密钥，用于打印可能包含密钥的变量值
+func masked(value interface{}) string {
+	return utils.MaskSecrets(fmt.Sprint(value))
+}
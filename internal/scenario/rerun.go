@@ -0,0 +1,102 @@
+package scenario
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/types"
+)
+
+// iterationSuffixPattern 匹配数据驱动场景迭代名称的后缀，如 "登录 [row 2: phone=138...]"
+var iterationSuffixPattern = regexp.MustCompile(` \[row \d+: .*\]$`)
+
+// phasePrefixPattern 匹配阶段步骤结果名称的前缀，如 "[setup] 创建用户"
+var phasePrefixPattern = regexp.MustCompile(`^\[(setup|teardown|before_all|after_all)\] `)
+
+// FailedSteps 上次运行中失败的步骤，按场景名称分组
+type FailedSteps struct {
+	// 场景名称 -> 失败步骤的结果名称
+	steps map[string][]string
+	// 整个场景都需要重新运行的场景（setup 或 teardown 失败）
+	whole map[string]bool
+	// before_all 失败时所有场景都需要重新运行
+	all bool
+}
+
+// NewFailedSteps 从测试结果中找出失败的步骤，跳过的步骤不算失败
+// 数据驱动场景的迭代按原场景名称归类，重新运行时会运行所有数据行
+func NewFailedSteps(results []*types.EndpointTestResult) *FailedSteps {
+	failed := &FailedSteps{
+		steps: make(map[string][]string),
+		whole: make(map[string]bool),
+	}
+
+	for _, result := range results {
+		if result.Validation == nil || result.Validation.Skipped || result.Validation.Passed {
+			continue
+		}
+
+		scenarioName := iterationSuffixPattern.ReplaceAllString(result.Scenario, "")
+		phase := phasePrefixPattern.FindStringSubmatch(result.Step)
+		switch {
+		case phase == nil:
+			failed.steps[scenarioName] = append(failed.steps[scenarioName], result.Step)
+		case phase[1] == "before_all":
+			failed.all = true
+		case phase[1] == "setup" || phase[1] == "teardown":
+			failed.whole[scenarioName] = true
+		}
+		// after_all 每次运行都会执行，不需要单独处理
+	}
+
+	return failed
+}
+
+// IsEmpty 判断是否没有失败的步骤
+func (f *FailedSteps) IsEmpty() bool {
+	return !f.all && len(f.steps) == 0 && len(f.whole) == 0
+}
+
+// Select 选出有失败步骤的场景，场景中只保留失败的步骤及其依赖的步骤
+func (f *FailedSteps) Select(scenarios []*yaml.Scenario) []*yaml.Scenario {
+	if f.all {
+		return scenarios
+	}
+
+	var selected []*yaml.Scenario
+	for _, scenario := range scenarios {
+		if f.whole[scenario.Name] {
+			selected = append(selected, scenario)
+			continue
+		}
+
+		names := f.steps[scenario.Name]
+		if len(names) == 0 {
+			continue
+		}
+		steps := selectStepsFunc(scenario.Steps, func(name string) bool {
+			return matchResultName(names, name)
+		})
+		if len(steps) == 0 {
+			continue
+		}
+
+		scenarioCopy := *scenario
+		scenarioCopy.Steps = steps
+		selected = append(selected, &scenarioCopy)
+	}
+
+	return selected
+}
+
+// matchResultName 判断步骤是否产生了其中某个结果
+// 模板、场景调用和循环的嵌套结果名称分别为 "步骤 > 嵌套步骤" 和 "步骤[i] 嵌套步骤"
+func matchResultName(resultNames []string, stepName string) bool {
+	for _, name := range resultNames {
+		if name == stepName || strings.HasPrefix(name, stepName+" > ") || strings.HasPrefix(name, stepName+"[") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package scenario
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/parser"
+	"github.com/gaoyong06/api-tester/internal/types"
+	"github.com/gaoyong06/api-tester/pkg/client"
+)
+
+const (
+	// defaultRetryDelay 第一次自动重试前的等待时间，之后每次翻倍
+	defaultRetryDelay = 500 * time.Millisecond
+	// maxRetryDelay 自动重试的最大等待时间
+	maxRetryDelay = 10 * time.Second
+)
+
+// defaultRetryOn 默认触发自动重试的状态码
+var defaultRetryOn = []int{502, 503, 504}
+
+// retryPolicy 请求的自动重试策略
+type retryPolicy struct {
+	// 最大重试次数，0 表示不重试
+	retries int
+	// 触发重试的状态码
+	retryOn []int
+	// 非幂等请求遇到网络错误时是否也重试
+	networkErrors bool
+}
+
+// idempotentMethods 遇到网络错误时可以安全重试的请求方法
+// 其他方法（如 POST）的请求可能已经被服务端处理，需要在 retry_on 中加入 network_error 才重试
+var idempotentMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"PUT":     true,
+	"DELETE":  true,
+	"OPTIONS": true,
+}
+
+// retryPolicy 计算步骤的自动重试策略，优先级：步骤 > 场景 > 全局配置
+func (m *Manager) retryPolicy(scenario *yaml.Scenario, step *yaml.Step) retryPolicy {
+	policy := retryPolicy{retryOn: defaultRetryOn}
+
+	if m.Config != nil {
+		policy.retries = m.Config.Retries
+		policy.applyRetryOn(m.Config.RetryOn)
+	}
+	// retries 未配置时为 nil，显式设置的 0 同样覆盖上一级配置
+	if scenario.Retries != nil {
+		policy.retries = *scenario.Retries
+	}
+	policy.applyRetryOn(scenario.RetryOn)
+	if step.Retries != nil {
+		policy.retries = *step.Retries
+	}
+	policy.applyRetryOn(step.RetryOn)
+
+	return policy
+}
+
+// applyRetryOn 使用配置的 retry_on 覆盖上一级的重试条件，未配置时保持不变
+func (p *retryPolicy) applyRetryOn(retryOn *yaml.RetryOn) {
+	if retryOn == nil {
+		return
+	}
+	p.retryOn = retryOn.StatusCodes
+	p.networkErrors = retryOn.NetworkErrors
+}
+
+// retryReason 判断 method 请求的响应是否需要重试，返回重试原因；不需要重试时返回空字符串
+func (p retryPolicy) retryReason(method string, response *client.Response) string {
+	if response.Error != nil {
+		if !p.networkErrors && !idempotentMethods[strings.ToUpper(method)] {
+			return ""
+		}
+		return response.Error.Error()
+	}
+	for _, status := range p.retryOn {
+		if response.StatusCode == status {
+			return fmt.Sprintf("状态码 %d", status)
+		}
+	}
+	return ""
+}
+
+// sendWithRetry 发送请求，遇到网络错误（非幂等请求需要 retry_on 包含 network_error）或 retry_on 中的状态码时
+// 按带抖动的指数退避自动重试
+// 返回最后一次响应以及被重试的每次请求的记录
+func (m *Manager) sendWithRetry(policy retryPolicy, endpoint *parser.Endpoint, pathParams, queryParams map[string]string, options *client.RequestOptions) (*client.Response, []types.AttemptResult, error) {
+	var retried []types.AttemptResult
+
+	for attempt := 1; ; attempt++ {
+		response, err := m.Client.SendRequest(m.ctx, endpoint, pathParams, queryParams, options)
+		if err != nil {
+			return nil, retried, err
+		}
+
+		reason := policy.retryReason(endpoint.Method, response)
+		if reason == "" || attempt > policy.retries || m.stopReason() != "" {
+			return response, retried, nil
+		}
+
+		retried = append(retried, types.AttemptResult{
+			Attempt:       attempt,
+			StatusCode:    response.StatusCode,
+			ResponseTime:  response.ResponseTime,
+			FailureReason: reason,
+			Time:          time.Now(),
+		})
+
+		delay := retryDelay(attempt)
+		fmt.Printf("请求失败 (%s)，%v 后自动重试 (%d/%d)\n", reason, delay, attempt, policy.retries)
+		m.sleep(delay)
+	}
+}
+
+// retryDelay 计算第 retry 次重试前的等待时间：指数退避，并在 [delay/2, delay) 范围内随机抖动
+func retryDelay(retry int) time.Duration {
+	delay := defaultRetryDelay
+	for i := 1; i < retry && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
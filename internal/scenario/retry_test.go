@@ -0,0 +1,210 @@
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/parser"
+	"github.com/gaoyong06/api-tester/internal/reporter/machine"
+	"github.com/gaoyong06/api-tester/pkg/client"
+)
+
+func TestRetryPolicyRetries(t *testing.T) {
+	intPtr := func(value int) *int { return &value }
+
+	tests := []struct {
+		name     string
+		global   int
+		scenario *int
+		step     *int
+		want     int
+	}{
+		{"global", 2, nil, nil, 2},
+		{"scenario overrides global", 2, intPtr(3), nil, 3},
+		{"step overrides scenario", 2, intPtr(3), intPtr(1), 1},
+		{"explicit zero on scenario", 2, intPtr(0), nil, 0},
+		{"explicit zero on step", 2, intPtr(3), intPtr(0), 0},
+		{"step overrides zero scenario", 0, intPtr(0), intPtr(2), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{Config: &yaml.Config{Retries: tt.global}}
+			policy := m.retryPolicy(&yaml.Scenario{Retries: tt.scenario}, &yaml.Step{Retries: tt.step})
+			if policy.retries != tt.want {
+				t.Errorf("retries = %d, want %d", policy.retries, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyRetryOn(t *testing.T) {
+	tests := []struct {
+		name              string
+		global            *yaml.RetryOn
+		scenario          *yaml.RetryOn
+		step              *yaml.RetryOn
+		wantRetryOn       []int
+		wantNetworkErrors bool
+	}{
+		{"default", nil, nil, nil, defaultRetryOn, false},
+		{"global", &yaml.RetryOn{StatusCodes: []int{429}}, nil, nil, []int{429}, false},
+		{"scenario overrides global", &yaml.RetryOn{StatusCodes: []int{429}}, &yaml.RetryOn{StatusCodes: []int{500}, NetworkErrors: true}, nil, []int{500}, true},
+		{"step overrides scenario", nil, &yaml.RetryOn{StatusCodes: []int{500}, NetworkErrors: true}, &yaml.RetryOn{StatusCodes: []int{503}}, []int{503}, false},
+		{"explicit empty list", nil, nil, &yaml.RetryOn{}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{Config: &yaml.Config{RetryOn: tt.global}}
+			policy := m.retryPolicy(&yaml.Scenario{RetryOn: tt.scenario}, &yaml.Step{RetryOn: tt.step})
+			if !reflect.DeepEqual(policy.retryOn, tt.wantRetryOn) {
+				t.Errorf("retryOn = %v, want %v", policy.retryOn, tt.wantRetryOn)
+			}
+			if policy.networkErrors != tt.wantNetworkErrors {
+				t.Errorf("networkErrors = %v, want %v", policy.networkErrors, tt.wantNetworkErrors)
+			}
+		})
+	}
+}
+
+func TestRetryReason(t *testing.T) {
+	networkError := &client.Response{Error: errors.New("发送请求失败: connection reset")}
+
+	tests := []struct {
+		name     string
+		policy   retryPolicy
+		method   string
+		response *client.Response
+		retry    bool
+	}{
+		{"network error on GET", retryPolicy{}, "GET", networkError, true},
+		{"network error on lowercase get", retryPolicy{}, "get", networkError, true},
+		{"network error on HEAD", retryPolicy{}, "HEAD", networkError, true},
+		{"network error on PUT", retryPolicy{}, "PUT", networkError, true},
+		{"network error on DELETE", retryPolicy{}, "DELETE", networkError, true},
+		{"network error on OPTIONS", retryPolicy{}, "OPTIONS", networkError, true},
+		{"network error on POST", retryPolicy{}, "POST", networkError, false},
+		{"network error on PATCH", retryPolicy{}, "PATCH", networkError, false},
+		{"network error on POST with opt-in", retryPolicy{networkErrors: true}, "POST", networkError, true},
+		{"retry_on status on POST", retryPolicy{retryOn: []int{503}}, "POST", &client.Response{StatusCode: 503}, true},
+		{"retry_on status on GET", retryPolicy{retryOn: []int{503}}, "GET", &client.Response{StatusCode: 503}, true},
+		{"other status", retryPolicy{retryOn: []int{503}}, "GET", &client.Response{StatusCode: 500}, false},
+		{"success", retryPolicy{retryOn: []int{503}}, "POST", &client.Response{StatusCode: 200}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := tt.policy.retryReason(tt.method, tt.response)
+			if (reason != "") != tt.retry {
+				t.Errorf("retryReason() = %q, want retry %v", reason, tt.retry)
+			}
+		})
+	}
+}
+
+func TestNetworkErrorRetryNeedsOptInForPost(t *testing.T) {
+	server := newRecordingServer(t, nil)
+	server.failures = map[string][]int{"/orders": {0}, "/payments": {0}}
+
+	config := &yaml.Config{
+		Retries: 1,
+		Scenarios: []yaml.Scenario{{
+			Name: "下单",
+			Steps: []yaml.Step{
+				{Name: "创建订单", Endpoint: "/orders", Method: "POST"},
+				{Name: "支付", Endpoint: "/payments", Method: "POST", RetryOn: &yaml.RetryOn{NetworkErrors: true}},
+			},
+		}},
+	}
+
+	results := runTestScenarios(t, server, config)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	// 没有 network_error 时 POST 请求遇到网络错误不重试
+	if got := len(server.query("/orders", "")); got != 1 {
+		t.Errorf("/orders requested %d times, want 1", got)
+	}
+	if results[0].Validation.Passed || results[0].Validation.Retries != 0 {
+		t.Errorf("创建订单: passed = %v, retries = %d, want failed without retries", results[0].Validation.Passed, results[0].Validation.Retries)
+	}
+
+	if got := len(server.query("/payments", "")); got != 2 {
+		t.Errorf("/payments requested %d times, want 2", got)
+	}
+	if !results[1].Validation.Passed || results[1].Validation.Retries != 1 {
+		t.Errorf("支付: passed = %v, retries = %d, want passed after 1 retry", results[1].Validation.Passed, results[1].Validation.Retries)
+	}
+}
+
+func TestPassAfterRetryIsFlaky(t *testing.T) {
+	server := newRecordingServer(t, nil)
+	server.failures = map[string][]int{"/orders": {503}}
+
+	config := &yaml.Config{
+		Retries: 2,
+		Scenarios: []yaml.Scenario{{
+			Name: "下单",
+			Steps: []yaml.Step{
+				{Name: "查询订单", Endpoint: "/orders", Method: "GET", Assert: map[string]interface{}{"status": 200}},
+				getStep("查询用户", "/users", nil),
+			},
+		}},
+	}
+
+	results := runTestScenarios(t, server, config)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	validation := results[0].Validation
+	if !validation.Passed || !validation.Flaky || validation.Retries != 1 {
+		t.Fatalf("查询订单: passed = %v, flaky = %v, retries = %d, want passed and flaky after 1 retry", validation.Passed, validation.Flaky, validation.Retries)
+	}
+	if len(validation.Attempts) != 2 || validation.Attempts[0].StatusCode != 503 || validation.Attempts[0].Passed || !validation.Attempts[1].Passed {
+		t.Errorf("查询订单: attempts = %+v, want a failed 503 attempt followed by a passed one", validation.Attempts)
+	}
+	if results[1].Validation.Flaky || results[1].Validation.Retries != 0 {
+		t.Errorf("查询用户: flaky = %v, retries = %d, want not flaky", results[1].Validation.Flaky, results[1].Validation.Retries)
+	}
+
+	// 报告中单独列出不稳定的步骤，仍计为通过
+	outputDir := t.TempDir()
+	path, err := machine.GenerateReport(&parser.APIDefinition{}, results, outputDir, "json", "")
+	if err != nil {
+		t.Fatalf("GenerateReport: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report machine.MachineReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Summary.Passed != 2 || report.Summary.Flaky != 1 {
+		t.Errorf("summary: passed = %d, flaky = %d, want 2 and 1", report.Summary.Passed, report.Summary.Flaky)
+	}
+	if want := []string{"下单 / 查询订单"}; !reflect.DeepEqual(report.Summary.FlakyTests, want) {
+		t.Errorf("flaky_tests = %v, want %v", report.Summary.FlakyTests, want)
+	}
+
+	path, err = machine.GenerateJUnitReport(&parser.APIDefinition{}, results, outputDir, "")
+	if err != nil {
+		t.Fatalf("GenerateJUnitReport: %v", err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "passed after 1 retries") {
+		t.Errorf("JUnit report does not mark the flaky test:\n%s", data)
+	}
+}
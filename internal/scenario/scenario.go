@@ -1,17 +1,20 @@
 package scenario
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gaoyong06/api-tester/internal/config/yaml"
 	"github.com/gaoyong06/api-tester/internal/parser"
 	"github.com/gaoyong06/api-tester/internal/types"
 	"github.com/gaoyong06/api-tester/pkg/client"
+	"github.com/gaoyong06/api-tester/pkg/utils"
 	"github.com/tidwall/gjson"
 )
 
@@ -25,9 +28,30 @@ type Manager struct {
 	Client *client.APIClient
 	// 上下文数据
 	Context *Context
+	// 配置
+	Config *yaml.Config
+	// 并发运行的最大场景数，大于 1 时并发运行场景
+	Parallel int
+	// 失败多少个步骤后停止执行剩余的步骤和场景，0 表示不限制
+	MaxFailures int
+	// 整个运行的停止状态（被中断或失败数达到上限），所有场景共享
+	stop *stopState
+	// 当前场景的停止状态（stop_on_failure）
+	scenarioStop *stopState
+	// 失败的步骤数，所有场景共享
+	failures *int32
+	// 是否正在执行 teardown / after_all，清理阶段不受中断影响
+	cleanup bool
+	// 运行的 context，被取消（中断、超过截止时间或场景超时）后停止执行新的步骤并中止正在发送的请求
+	ctx context.Context
+	// call 调用链上的场景名称，用于检测循环调用
+	callStack []string
+	// 当前步骤中无法解析的变量、未替换的路径参数和提取失败的变量，严格模式下使步骤失败
+	problems *[]string
 }
 
 // Context 测试上下文
+// 并行执行步骤时应通过 GetVariable、SetVariable 等方法访问，不要直接读写字段
 type Context struct {
 	// 变量存储
 	Variables map[string]interface{}
@@ -35,6 +59,16 @@ type Context struct {
 	Results map[string]*types.EndpointTestResult
 	// 步骤状态
 	StepStatus map[string]bool
+	// 从响应中提取的变量，call 调用场景结束后会复制回调用方
+	Extracted map[string]interface{}
+
+	// 保护以上字段的读写锁
+	mu sync.RWMutex
+
+	// 局部变量（循环的 item / index、步骤模板的参数），只在作用域上下文中可见，优先于 Variables
+	locals map[string]interface{}
+	// 作用域上下文的外层上下文，变量写入、结果和步骤状态都委托给外层上下文
+	parent *Context
 }
 
 // NewManager 创建场景管理器
@@ -64,6 +98,10 @@ func NewManager(scenarios []*yaml.Scenario, apiDef *parser.APIDefinition, client
 		// 将全局变量添加到上下文变量中
 		for k, v := range config.Variables {
 			variables[k] = v
+			// 名称像密钥的变量（如 api_token）的值在日志和报告中屏蔽
+			if str, ok := v.(string); ok && utils.IsSecretName(k) {
+				utils.RegisterSecret(str)
+			}
 		}
 
 		fmt.Printf("从配置中加载了 %d 个全局变量\n", len(config.Variables))
@@ -77,12 +115,17 @@ func NewManager(scenarios []*yaml.Scenario, apiDef *parser.APIDefinition, client
 			Variables:  variables,
 			Results:    make(map[string]*types.EndpointTestResult),
 			StepStatus: make(map[string]bool),
+			Extracted:  make(map[string]interface{}),
 		},
+		Config:   config,
+		stop:     &stopState{},
+		failures: new(int32),
+		ctx:      context.Background(),
 	}
 }
 
-// RunScenario 运行指定场景
-func (m *Manager) RunScenario(scenarioName string) ([]*types.EndpointTestResult, error) {
+// RunScenario 运行指定场景，ctx 被取消时停止执行剩余步骤，但仍会执行清理步骤
+func (m *Manager) RunScenario(ctx context.Context, scenarioName string) ([]*types.EndpointTestResult, error) {
 	// 查找场景
 	var scenario *yaml.Scenario
 	for _, s := range m.Scenarios {
@@ -97,144 +140,361 @@ func (m *Manager) RunScenario(scenarioName string) ([]*types.EndpointTestResult,
 	}
 
 	// 运行场景
-	return m.runScenarioSteps(scenario)
+	m.ctx = ctx
+	return m.runSuite([]*yaml.Scenario{scenario})
 }
 
-// RunAllScenarios 运行所有场景
-func (m *Manager) RunAllScenarios() ([]*types.EndpointTestResult, error) {
-	var allResults []*types.EndpointTestResult
-
-	for _, scenario := range m.Scenarios {
-		results, err := m.runScenarioSteps(scenario)
-		if err != nil {
-			return nil, err
-		}
-
-		allResults = append(allResults, results...)
-	}
-
-	return allResults, nil
+// RunAllScenarios 运行所有场景，ctx 被取消时停止执行剩余步骤，但仍会执行清理步骤
+func (m *Manager) RunAllScenarios(ctx context.Context) ([]*types.EndpointTestResult, error) {
+	m.ctx = ctx
+	return m.runSuite(m.Scenarios)
 }
 
 // runScenarioSteps 运行场景步骤
 func (m *Manager) runScenarioSteps(scenario *yaml.Scenario) ([]*types.EndpointTestResult, error) {
-	var results []*types.EndpointTestResult
-
 	fmt.Printf("运行场景: %s\n", scenario.Name)
 	if scenario.Description != "" {
 		fmt.Printf("描述: %s\n", scenario.Description)
 	}
 
+	// 检查步骤依赖是否存在循环
+	if _, err := buildStepGraph(scenario.Steps); err != nil {
+		return nil, fmt.Errorf("场景 %s 的步骤依赖无效: %v", scenario.Name, err)
+	}
+
 	// 重置步骤状态
-	m.Context.StepStatus = make(map[string]bool)
+	m.Context.ClearStepStatus()
 
-	// 运行所有步骤
-	for _, step := range scenario.Steps {
-		// 检查依赖是否已完成
-		if !m.checkDependencies(&step) {
-			fmt.Printf("跳过步骤 %s，因为依赖未满足\n", step.Name)
-			continue
-		}
+	var results []*types.EndpointTestResult
 
-		fmt.Printf("执行步骤: %s (%s %s)\n", step.Name, step.Method, step.Endpoint)
-
-		// 查找端点
-		endpoint := m.findEndpoint(step.Endpoint, step.Method)
-		if endpoint == nil {
-			fmt.Printf("警告: 未在 API 定义中找到端点 %s %s\n", step.Method, step.Endpoint)
-			// 创建一个临时端点
-			endpoint = &parser.Endpoint{
-				Path:        step.Endpoint,
-				Method:      step.Method,
-				OperationID: step.Name,
-				Description: step.Name,
-			}
+	// 运行 setup 步骤，失败时跳过场景的主体步骤
+	setupPassed := true
+	if len(scenario.Setup) > 0 {
+		setupResults := m.runPhase(scenario, "setup", scenario.Setup)
+		results = append(results, setupResults...)
+		setupPassed = allPassed(setupResults)
+	}
+
+	// 运行所有步骤，配置了 parallel 时按依赖关系并行执行
+	if setupPassed {
+		if scenario.Parallel > 1 {
+			results = append(results, m.runStepsParallel(scenario, scenario.Steps, scenario.Parallel)...)
+		} else {
+			results = append(results, m.runSteps(scenario, scenario.Steps)...)
 		}
+	} else {
+		results = append(results, m.skipSteps(scenario, scenario.Steps, "setup 失败")...)
+	}
 
-		// 处理变量替换
-		pathParams, queryParams, requestBody := m.processVariables(&step)
+	// 无论前面的步骤是否失败或运行是否被中断，都运行 teardown 步骤
+	if len(scenario.Teardown) > 0 {
+		results = append(results, m.runCleanup(scenario, "teardown", scenario.Teardown)...)
+	}
 
-		// 更新端点路径，使用处理后的路径
-		endpoint.Path = step.Endpoint
+	return results, nil
+}
 
-		// 发送请求，包括请求体
-		response, err := m.Client.SendRequest(endpoint, pathParams, queryParams, requestBody)
-		if err != nil {
-			fmt.Printf("请求失败: %v\n", err)
-			continue
-		}
+// runSteps 按顺序运行一组步骤，foreach 步骤会对数组中的每个元素运行其嵌套步骤
+func (m *Manager) runSteps(scenario *yaml.Scenario, steps []yaml.Step) []*types.EndpointTestResult {
+	var results []*types.EndpointTestResult
 
-		// 提取变量
-		if len(step.Extract) > 0 && len(response.Body) > 0 {
-			m.extractVariables(step.Extract, response.Body)
-		}
+	for _, step := range steps {
+		results = append(results, m.executeStep(scenario, step)...)
+	}
+
+	return results
+}
+
+// executeStep 检查中断、依赖和执行条件后运行单个步骤，返回步骤产生的结果
+// step 按值传递，变量替换不会修改配置中的步骤
+func (m *Manager) executeStep(scenario *yaml.Scenario, step yaml.Step) []*types.EndpointTestResult {
+	// 运行被中断或因失败停止时跳过剩余步骤
+	if reason := m.stopReason(); reason != "" {
+		result := m.skippedResult(scenario, &step, notRunPrefix+reason)
+		m.Context.SetResult(step.Name, result)
+		return []*types.EndpointTestResult{result}
+	}
+
+	// 检查依赖是否已完成
+	if !m.checkDependencies(&step) {
+		fmt.Printf("跳过步骤 %s，因为依赖未满足\n", step.Name)
+		result := m.skippedResult(scenario, &step, "依赖未满足: "+strings.Join(step.Dependencies, ", "))
+		m.Context.SetResult(step.Name, result)
+		return []*types.EndpointTestResult{result}
+	}
+
+	// 检查执行条件
+	if run, reason := m.checkConditions(&step); !run {
+		fmt.Printf("跳过步骤 %s，%s\n", step.Name, reason)
+		result := m.skippedResult(scenario, &step, reason)
+		m.Context.SetResult(step.Name, result)
+		return []*types.EndpointTestResult{result}
+	}
+
+	// 使用步骤模板
+	if step.Use != "" {
+		results := m.runTemplate(scenario, &step)
+		m.Context.SetResult(step.Name, summaryResult(scenario, &step, results))
+		m.Context.MarkStepDone(step.Name)
+		return results
+	}
+
+	// 调用其他场景
+	if step.Call != "" {
+		results := m.runCall(scenario, &step)
+		m.Context.SetResult(step.Name, summaryResult(scenario, &step, results))
+		m.Context.MarkStepDone(step.Name)
+		return results
+	}
+
+	// 循环步骤
+	if step.Foreach != "" {
+		results := m.runForeach(scenario, &step)
+		m.Context.SetResult(step.Name, summaryResult(scenario, &step, results))
+		m.Context.MarkStepDone(step.Name)
+		return results
+	}
+
+	result := m.runStep(scenario, &step)
+
+	// 保存结果
+	m.Context.SetResult(step.Name, result)
+	m.recordOutcome(scenario, result)
+
+	// 标记步骤已完成
+	m.Context.MarkStepDone(step.Name)
+
+	// 打印结果
+	if result.Validation.Skipped {
+		fmt.Printf("步骤跳过: %s - %s\n", step.Name, result.Validation.SkipReason)
+	} else if result.Validation.Flaky {
+		fmt.Printf("步骤成功: %s (%d ms)，自动重试 %d 次后通过，标记为不稳定\n", step.Name, result.Validation.ResponseTime, result.Validation.Retries)
+	} else if result.Validation.Passed {
+		fmt.Printf("步骤成功: %s (%d ms)\n", step.Name, result.Validation.ResponseTime)
+	} else {
+		fmt.Printf("步骤失败: %s - %s\n", step.Name, result.Validation.FailureReason)
+	}
+
+	return []*types.EndpointTestResult{result}
+}
+
+// runStep 执行单个步骤：变量替换、发送请求（含轮询）、提取变量和验证响应
+func (m *Manager) runStep(scenario *yaml.Scenario, step *yaml.Step) *types.EndpointTestResult {
+	m = m.forStep()
+	fmt.Printf("执行步骤: %s (%s %s)\n", step.Name, step.Method, step.Endpoint)
+
+	// 查找端点，使用副本避免并行步骤修改 API 定义中共享的端点
+	var endpoint *parser.Endpoint
+	if found := m.findEndpoint(step.Endpoint, step.Method); found != nil {
+		endpointCopy := *found
+		endpoint = &endpointCopy
+	} else {
+		fmt.Printf("警告: 未在 API 定义中找到端点 %s %s\n", step.Method, step.Endpoint)
+		// 创建一个临时端点
+		endpoint = newStepEndpoint(step)
+	}
+
+	// 处理变量替换
+	pathParams, queryParams, requestBody := m.processVariables(step)
+
+	// 更新端点路径，使用处理后的路径
+	endpoint.Path = step.Endpoint
+
+	// 处理请求头和基础URL
+	headers, baseURL := m.processRequestHeaders(scenario, step)
+
+	// 认证方式
+	auth := m.requestAuth(scenario, step)
+
+	// 严格模式下存在无法解析的变量时不发送请求
+	if failure := m.strictFailure(); failure != "" {
+		result := m.failedResult(scenario, step, failure)
+		result.Endpoint = endpoint
+		return result
+	}
+
+	// 步骤的请求超时时间
+	timeout, err := parseDuration(step.Timeout, 0)
+	if err != nil {
+		result := m.failedResult(scenario, step, fmt.Sprintf("无效的超时时间: %v", err))
+		result.Endpoint = endpoint
+		return result
+	}
+
+	// 发送请求，包括请求体；配置了轮询时会重复发送直到满足条件，配置了 retries 时自动重试
+	response, attempts, retries, err := m.sendWithPolling(scenario, step, endpoint, pathParams, queryParams, &client.RequestOptions{
+		BaseURL: baseURL,
+		Headers: headers,
+		Body:    requestBody,
+		Timeout: timeout,
+		Auth:    auth,
+	})
+	if err != nil {
+		fmt.Printf("请求失败: %v\n", err)
+		result := m.failedResult(scenario, step, fmt.Sprintf("请求失败: %v", err))
+		result.Endpoint = endpoint
+		result.Validation.Attempts = attempts
+		result.Validation.Retries = retries
+		return result
+	}
+
+	// 提取变量
+	if len(step.Extract) > 0 {
+		m.extractVariables(step.Extract, response)
+	}
 
-		// 验证响应
-		passed, failureReason := m.validateResponse(&step, response)
-
-		// 创建测试结果
-		result := &types.EndpointTestResult{
-			Endpoint: endpoint,
-			Validation: &types.ValidationResult{
-				Passed:        passed,
-				ActualStatus:  response.StatusCode,
-				ResponseTime:  response.ResponseTime,
-				ResponseBody:  string(response.Body),
-				FailureReason: failureReason,
-			},
-			TestTime: time.Now(),
+	// 验证响应
+	passed, failureReason, assertions := m.validateResponse(step.Assert, response)
+	if response.Error != nil {
+		passed, failureReason = false, response.Error.Error()
+	}
+	// 严格模式下提取失败或断言中有无法解析的变量时步骤失败
+	if failure := m.strictFailure(); failure != "" {
+		passed = false
+		if failureReason != "" {
+			failureReason += "; "
 		}
+		failureReason += failure
+	}
+	expectedStatus := ""
+	if status, ok := step.Assert["status"]; ok {
+		expectedStatus = m.formatExpectedStatus(status)
+	}
 
-		// 保存结果
-		m.Context.Results[step.Name] = result
-		results = append(results, result)
+	// 创建测试结果
+	return &types.EndpointTestResult{
+		Scenario: scenario.Name,
+		Step:     step.Name,
+		Endpoint: endpoint,
+		Validation: &types.ValidationResult{
+			Passed:         passed,
+			ExpectedStatus: expectedStatus,
+			ActualStatus:   response.StatusCode,
+			ResponseTime:   response.ResponseTime,
+			ResponseBody:   string(response.Body),
+			FailureReason:  failureReason,
+			Assertions:     assertions,
+			Attempts:       attempts,
+			Retries:        retries,
+			Flaky:          passed && retries > 0,
+		},
+		TestTime: time.Now(),
+	}
+}
 
-		// 标记步骤已完成
-		m.Context.StepStatus[step.Name] = true
+// checkConditions 计算步骤的 if / unless 条件，返回 (是否执行, 不执行的原因)
+// 条件表达式无效时不执行步骤
+func (m *Manager) checkConditions(step *yaml.Step) (bool, string) {
+	if step.If != "" {
+		ok, err := m.evaluateCondition(step.If)
+		if err != nil {
+			return false, fmt.Sprintf("if 条件无效: %v", err)
+		}
+		if !ok {
+			return false, fmt.Sprintf("if 条件不满足: %s", step.If)
+		}
+	}
 
-		// 打印结果
-		if result.Validation.Passed {
-			fmt.Printf("步骤成功: %s (%d ms)\n", step.Name, result.Validation.ResponseTime)
-		} else {
-			fmt.Printf("步骤失败: %s - %s\n", step.Name, result.Validation.FailureReason)
+	for _, unless := range []string{step.Unless, step.SkipIf} {
+		if unless == "" {
+			continue
+		}
+		skip, err := m.evaluateCondition(unless)
+		if err != nil {
+			return false, fmt.Sprintf("unless 条件无效: %v", err)
+		}
+		if skip {
+			return false, fmt.Sprintf("unless 条件满足: %s", unless)
 		}
 	}
 
-	return results, nil
+	return true, ""
+}
+
+// skippedResult 创建跳过步骤的测试结果
+func (m *Manager) skippedResult(scenario *yaml.Scenario, step *yaml.Step, reason string) *types.EndpointTestResult {
+	return &types.EndpointTestResult{
+		Scenario: scenario.Name,
+		Step:     step.Name,
+		Endpoint: newStepEndpoint(step),
+		Validation: &types.ValidationResult{
+			Skipped:    true,
+			SkipReason: reason,
+		},
+		TestTime: time.Now(),
+	}
+}
+
+// newStepEndpoint 为未在 API 定义中找到的步骤创建临时端点
+func newStepEndpoint(step *yaml.Step) *parser.Endpoint {
+	return &parser.Endpoint{
+		Path:        step.Endpoint,
+		Method:      step.Method,
+		OperationID: step.Name,
+		Description: step.Name,
+	}
 }
 
 // validateResponse 验证响应是否符合断言
-func (m *Manager) validateResponse(step *yaml.Step, response *client.Response) (bool, string) {
+// 会执行所有断言而不是在第一个失败处停止，返回 (是否全部通过, 失败原因汇总, 每条断言的结果)
+func (m *Manager) validateResponse(assert map[string]interface{}, response *client.Response) (bool, string, []types.AssertionResult) {
+	var assertions []types.AssertionResult
+	actualStatus := strconv.Itoa(response.StatusCode)
+
 	// 如果没有断言配置，默认只检查 2xx 状态码
-	if step.Assert == nil || len(step.Assert) == 0 {
-		if response.StatusCode >= 200 && response.StatusCode < 300 {
-			return true, ""
+	if len(assert) == 0 {
+		passed := response.StatusCode >= 200 && response.StatusCode < 300
+		message := ""
+		if !passed {
+			message = fmt.Sprintf("状态码 %d 不在成功范围内 (2xx)", response.StatusCode)
 		}
-		return false, fmt.Sprintf("状态码 %d 不在成功范围内 (2xx)", response.StatusCode)
+		assertions = append(assertions, newAssertion("status", "in", "2xx", actualStatus, passed, message))
+		return summarizeAssertions(assertions)
 	}
 
 	// 验证状态码
-	if expectedStatus, ok := step.Assert["status"]; ok {
-		if !m.validateStatusCode(response.StatusCode, expectedStatus) {
-			// 格式化期望状态码，使其更清晰
-			expectedStatusStr := m.formatExpectedStatus(expectedStatus)
-			return false, fmt.Sprintf("期望状态码 %s，实际状态码 %d", expectedStatusStr, response.StatusCode)
+	if expectedStatus, ok := assert["status"]; ok {
+		// 格式化期望状态码，使其更清晰
+		expectedStatusStr := m.formatExpectedStatus(expectedStatus)
+		operator := "eq"
+		if _, isList := expectedStatus.([]interface{}); isList {
+			operator = "in"
 		}
+		passed := m.validateStatusCode(response.StatusCode, expectedStatus)
+		message := ""
+		if !passed {
+			message = fmt.Sprintf("期望状态码 %s，实际状态码 %d", expectedStatusStr, response.StatusCode)
+		}
+		assertions = append(assertions, newAssertion("status", operator, expectedStatusStr, actualStatus, passed, message))
 	}
 
 	// 验证响应体
-	if expectedBody, ok := step.Assert["body"]; ok {
-		if bodyMap, ok := expectedBody.(map[string]interface{}); ok {
-			for jsonPath, expectedValue := range bodyMap {
-				passed, detailErr := m.validateJSONPath(response.Body, jsonPath, expectedValue)
-				if !passed {
-					return false, detailErr
-				}
-			}
+	if bodyMap, ok := assert["body"].(map[string]interface{}); ok {
+		for _, jsonPath := range sortedKeys(bodyMap) {
+			assertions = append(assertions, m.validateJSONPath(response.Body, jsonPath, bodyMap[jsonPath]))
 		}
 	}
 
-	return true, ""
+	// 验证响应头
+	if expectedHeaders, ok := assert["headers"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(expectedHeaders) {
+			assertions = append(assertions, m.validateHeader(response, name, expectedHeaders[name]))
+		}
+	}
+
+	// 验证 Content-Type
+	if expectedContentType, ok := assert["content_type"]; ok {
+		assertions = append(assertions, m.validateContentType(response, expectedContentType))
+	}
+
+	// 验证 Cookie
+	if expectedCookies, ok := assert["cookies"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(expectedCookies) {
+			assertions = append(assertions, m.validateCookie(response, name, expectedCookies[name]))
+		}
+	}
+
+	return summarizeAssertions(assertions)
 }
 
 // formatExpectedStatus 格式化期望状态码，使其更清晰
@@ -297,18 +557,20 @@ func (m *Manager) validateStatusCode(actualStatus int, expected interface{}) boo
 }
 
 // validateJSONPath 验证 JSON 路径的值是否匹配期望值
-// 返回 (是否通过, 详细错误信息)
-func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue interface{}) (bool, string) {
+func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue interface{}) types.AssertionResult {
 	// 去除 $. 前缀（如果有）
 	originalPath := jsonPath
 	jsonPath = strings.TrimPrefix(jsonPath, "$.")
 
-	// 替换 JSON 路径中的变量（支持 {{.var}} 格式）
-	jsonPath = m.replaceGoTemplateVars(jsonPath)
-	jsonPath = m.replaceVariables(jsonPath)
+	// 渲染 JSON 路径中的模板（如 {{.var}}）
+	jsonPath = m.render(jsonPath)
 
 	// 使用 gjson 提取值
 	result := gjson.GetBytes(body, jsonPath)
+	if !result.Exists() && expectsAbsent(expectedValue) {
+		// 结构化断言 {exists: false} 期望路径不存在
+		return newValueAssertion(originalPath, expectedValue, missingActual, true, "")
+	}
 	if !result.Exists() {
 		// 尝试使用点号语法（grants.0.userId 而不是 grants[0].userId）
 		dotPath := regexp.MustCompile(`\[(\d+)\]`).ReplaceAllString(jsonPath, ".$1")
@@ -319,27 +581,39 @@ func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue i
 				jsonPath = dotPath
 			} else {
 				fmt.Printf("  [DEBUG] JSON路径 %s 和 %s 都不存在\n", jsonPath, dotPath)
-				return false, fmt.Sprintf("JSON路径 %s 不存在于响应中 (尝试了 %s 和 %s)", originalPath, jsonPath, dotPath)
+				return newValueAssertion(originalPath, expectedValue, missingActual, false,
+					fmt.Sprintf("JSON路径 %s 不存在于响应中 (尝试了 %s 和 %s)", originalPath, jsonPath, dotPath))
 			}
 		} else {
 			fmt.Printf("  [DEBUG] JSON路径 %s 不存在 (原始路径: %s)\n", jsonPath, originalPath)
-			return false, fmt.Sprintf("JSON路径 %s 不存在于响应中", originalPath)
+			return newValueAssertion(originalPath, expectedValue, missingActual, false,
+				fmt.Sprintf("JSON路径 %s 不存在于响应中", originalPath))
 		}
 	}
 
-	// 比较值
+	passed, message := m.compareResult("JSON路径 "+originalPath, result, expectedValue)
+	return newValueAssertion(originalPath, expectedValue, truncateDisplay(result.Raw), passed, message)
+}
+
+// compareResult 比较实际值与期望值
+// label 用于错误信息的前缀（如 "JSON路径 $.id"、"响应头 Location"），返回 (是否通过, 详细错误信息)
+func (m *Manager) compareResult(label string, result gjson.Result, expectedValue interface{}) (bool, string) {
+	// 结构化断言，如 {length: ">=1"}、{matches: "^usr_"}
+	if matcher, ok := isMatcher(expectedValue); ok {
+		return m.compareMatcher(label, result, matcher)
+	}
+
 	switch expected := expectedValue.(type) {
 	case string:
 		if expected == "!null" {
 			// 特殊值：检查不为 null
 			if result.Type == gjson.Null {
-				return false, fmt.Sprintf("JSON路径 %s 的值为 null，期望不为 null", originalPath)
+				return false, fmt.Sprintf("%s 的值为 null，期望不为 null", label)
 			}
 			return true, ""
 		}
-		// 替换变量（支持 {{.var}} 格式）
-		expected = m.replaceGoTemplateVars(expected)
-		expected = m.replaceVariables(expected)
+		// 渲染期望值中的模板（如 {{.var}}）
+		expected = m.render(expected)
 
 		// 获取实际值
 		actualValue := result.String()
@@ -348,6 +622,19 @@ func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue i
 			actualValueDisplay = actualValueDisplay[:100] + "..."
 		}
 
+		// 支持正则匹配：=~^usr_
+		if strings.HasPrefix(expected, "=~") {
+			pattern := strings.TrimPrefix(expected, "=~")
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, fmt.Sprintf("%s: 无效的正则表达式 %s: %v", label, pattern, err)
+			}
+			if re.MatchString(actualValue) {
+				return true, ""
+			}
+			return false, fmt.Sprintf("%s: 实际值 \"%s\" 不匹配正则 %s", label, actualValueDisplay, pattern)
+		}
+
 		// 支持比较操作符：>0, >=0, <100, <=100 等
 		if strings.HasPrefix(expected, ">=") {
 			// >= 比较
@@ -357,7 +644,7 @@ func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue i
 				if val >= threshold {
 					return true, ""
 				}
-				return false, fmt.Sprintf("JSON路径 %s: 实际值 %v 不满足 >= %v", originalPath, val, threshold)
+				return false, fmt.Sprintf("%s: 实际值 %v 不满足 >= %v", label, val, threshold)
 			}
 		} else if strings.HasPrefix(expected, "<=") {
 			// <= 比较
@@ -367,7 +654,7 @@ func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue i
 				if val <= threshold {
 					return true, ""
 				}
-				return false, fmt.Sprintf("JSON路径 %s: 实际值 %v 不满足 <= %v", originalPath, val, threshold)
+				return false, fmt.Sprintf("%s: 实际值 %v 不满足 <= %v", label, val, threshold)
 			}
 		} else if strings.HasPrefix(expected, ">") {
 			// > 比较
@@ -377,7 +664,7 @@ func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue i
 				if val > threshold {
 					return true, ""
 				}
-				return false, fmt.Sprintf("JSON路径 %s: 实际值 %v 不满足 > %v", originalPath, val, threshold)
+				return false, fmt.Sprintf("%s: 实际值 %v 不满足 > %v", label, val, threshold)
 			}
 		} else if strings.HasPrefix(expected, "<") {
 			// < 比较
@@ -387,7 +674,7 @@ func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue i
 				if val < threshold {
 					return true, ""
 				}
-				return false, fmt.Sprintf("JSON路径 %s: 实际值 %v 不满足 < %v", originalPath, val, threshold)
+				return false, fmt.Sprintf("%s: 实际值 %v 不满足 < %v", label, val, threshold)
 			}
 		}
 
@@ -398,7 +685,7 @@ func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue i
 				if actualBool == expectedBool {
 					return true, ""
 				}
-				return false, fmt.Sprintf("JSON路径 %s: 实际值 %v (bool) 不等于期望值 %v", originalPath, actualBool, expectedBool)
+				return false, fmt.Sprintf("%s: 实际值 %v (bool) 不等于期望值 %v", label, actualBool, expectedBool)
 			}
 		}
 
@@ -410,14 +697,14 @@ func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue i
 				if actualInt == expectedInt {
 					return true, ""
 				}
-				return false, fmt.Sprintf("JSON路径 %s: 实际值 %d (int) 不等于期望值 %d", originalPath, actualInt, expectedInt)
+				return false, fmt.Sprintf("%s: 实际值 %d (int) 不等于期望值 %d", label, actualInt, expectedInt)
 			}
 			if expectedFloat, err := strconv.ParseFloat(expected, 64); err == nil {
 				actualFloat := result.Float()
 				if actualFloat == expectedFloat {
 					return true, ""
 				}
-				return false, fmt.Sprintf("JSON路径 %s: 实际值 %v (float) 不等于期望值 %v", originalPath, actualFloat, expectedFloat)
+				return false, fmt.Sprintf("%s: 实际值 %v (float) 不等于期望值 %v", label, actualFloat, expectedFloat)
 			}
 		}
 		// 字符串比较（去除引号）
@@ -426,44 +713,48 @@ func (m *Manager) validateJSONPath(body []byte, jsonPath string, expectedValue i
 		if actualStr == expectedStr {
 			return true, ""
 		}
-		return false, fmt.Sprintf("JSON路径 %s: 实际值 \"%s\" 不等于期望值 \"%s\"", originalPath, actualValueDisplay, expectedStr)
+		return false, fmt.Sprintf("%s: 实际值 \"%s\" 不等于期望值 \"%s\"", label, actualValueDisplay, expectedStr)
 	case int:
 		actualInt := result.Int()
 		if actualInt == int64(expected) {
 			return true, ""
 		}
-		return false, fmt.Sprintf("JSON路径 %s: 实际值 %d (int) 不等于期望值 %d", originalPath, actualInt, expected)
+		return false, fmt.Sprintf("%s: 实际值 %d (int) 不等于期望值 %d", label, actualInt, expected)
 	case float64:
 		actualFloat := result.Float()
 		if actualFloat == expected {
 			return true, ""
 		}
-		return false, fmt.Sprintf("JSON路径 %s: 实际值 %v (float) 不等于期望值 %v", originalPath, actualFloat, expected)
+		return false, fmt.Sprintf("%s: 实际值 %v (float) 不等于期望值 %v", label, actualFloat, expected)
 	case bool:
 		actualBool := result.Bool()
 		if actualBool == expected {
 			return true, ""
 		}
-		return false, fmt.Sprintf("JSON路径 %s: 实际值 %v (bool) 不等于期望值 %v", originalPath, actualBool, expected)
+		return false, fmt.Sprintf("%s: 实际值 %v (bool) 不等于期望值 %v", label, actualBool, expected)
 	default:
-		// 对于复杂类型，转换为 JSON 字符串比较
-		expectedJSON, _ := json.Marshal(expected)
-		actualRaw := result.Raw
-		if actualRaw == string(expectedJSON) {
-			return true, ""
-		}
-		actualDisplay := actualRaw
-		if len(actualDisplay) > 100 {
-			actualDisplay = actualDisplay[:100] + "..."
-		}
-		expectedDisplay := string(expectedJSON)
-		if len(expectedDisplay) > 100 {
-			expectedDisplay = expectedDisplay[:100] + "..."
-		}
-		return false, fmt.Sprintf("JSON路径 %s: 实际值 %s 不等于期望值 %s", originalPath, actualDisplay, expectedDisplay)
+		return compareJSON(label, result, expected)
 	}
 }
 
+// compareJSON 将复杂类型的期望值转换为 JSON 字符串与实际值比较
+func compareJSON(label string, result gjson.Result, expected interface{}) (bool, string) {
+	expectedJSON, _ := json.Marshal(expected)
+	actualRaw := result.Raw
+	if actualRaw == string(expectedJSON) {
+		return true, ""
+	}
+	actualDisplay := actualRaw
+	if len(actualDisplay) > 100 {
+		actualDisplay = actualDisplay[:100] + "..."
+	}
+	expectedDisplay := string(expectedJSON)
+	if len(expectedDisplay) > 100 {
+		expectedDisplay = expectedDisplay[:100] + "..."
+	}
+	return false, fmt.Sprintf("%s: 实际值 %s 不等于期望值 %s", label, actualDisplay, expectedDisplay)
+}
+
 // checkDependencies 检查依赖是否已满足
 func (m *Manager) checkDependencies(step *yaml.Step) bool {
 	if len(step.Dependencies) == 0 {
@@ -471,7 +762,7 @@ func (m *Manager) checkDependencies(step *yaml.Step) bool {
 	}
 
 	for _, dep := range step.Dependencies {
-		if !m.Context.StepStatus[dep] {
+		if !m.Context.IsStepDone(dep) {
 			return false
 		}
 	}
@@ -501,36 +792,28 @@ func (m *Manager) processVariables(step *yaml.Step) (map[string]string, map[stri
 
 	// 调试：检查 RequestBody 是否被正确读取
 	fmt.Printf("DEBUG processVariables: step.RequestBody type: %T, value: %v, is nil: %v\n",
-		step.RequestBody, step.RequestBody, step.RequestBody == nil)
+		step.RequestBody, masked(step.RequestBody), step.RequestBody == nil)
 
 	// 处理路径参数
 	if step.PathParams != nil {
 		for key, value := range step.PathParams {
-			// 替换变量
-			// 先处理Go模板语法
-			value = m.replaceGoTemplateVars(value)
-			// 再处理普通变量
-			pathParams[key] = m.replaceVariables(value)
-			fmt.Printf("路径参数: %s = %s\n", key, pathParams[key])
+			pathParams[key] = m.render(value)
+			fmt.Printf("路径参数: %s = %s\n", key, masked(pathParams[key]))
 		}
 	}
 
 	// 处理查询参数
 	if step.QueryParams != nil {
 		for key, value := range step.QueryParams {
-			// 替换变量
-			// 先处理Go模板语法
-			value = m.replaceGoTemplateVars(value)
-			// 再处理普通变量
-			queryParams[key] = m.replaceVariables(value)
-			fmt.Printf("查询参数: %s = %s\n", key, queryParams[key])
+			queryParams[key] = m.render(value)
+			fmt.Printf("查询参数: %s = %s\n", key, masked(queryParams[key]))
 		}
 	}
 
 	// 处理端点路径中的变量占位符
 	if step.Endpoint != "" {
-		// 匹配并替换路径中的参数占位符 {param_name}
-		endpoint := step.Endpoint
+		// 先渲染路径中的模板（如 /users/{{.user_id}}），再匹配并替换参数占位符 {param_name}
+		endpoint := m.renderTemplate(step.Endpoint)
 
 		// 查找所有占位符 {param_name}
 		re := regexp.MustCompile(`\{([^}]+)\}`)
@@ -538,7 +821,7 @@ func (m *Manager) processVariables(step *yaml.Step) (map[string]string, map[stri
 
 		// 记录占位符替换过程
 		fmt.Printf("开始处理端点: %s\n", endpoint)
-		fmt.Printf("变量替换优先级: 1.路径参数 > 2.上下文变量 > 3.相似名称变量 > 4.默认值\n")
+		fmt.Printf("变量替换优先级: 1.路径参数 > 2.上下文变量 > 3.相似名称变量（fuzzy_variables） > 4.默认值\n")
 
 		for _, match := range matches {
 			if len(match) > 1 {
@@ -551,17 +834,17 @@ func (m *Manager) processVariables(step *yaml.Step) (map[string]string, map[stri
 				// 1. 首先检查 path_params 中是否有对应的值
 				if value, exists := pathParams[paramName]; exists {
 					endpoint = strings.ReplaceAll(endpoint, placeholder, value)
-					fmt.Printf("  [优先级1] 替换占位符 %s 为路径参数值: %s\n", placeholder, value)
+					fmt.Printf("  [优先级1] 替换占位符 %s 为路径参数值: %s\n", placeholder, masked(value))
 					continue
 				} else {
 					fmt.Printf("  [优先级1] 路径参数中未找到 %s 的值\n", paramName)
 				}
 
 				// 2. 检查上下文变量
-				if value, exists := m.Context.Variables[paramName]; exists {
+				if value, exists := m.Context.GetVariable(paramName); exists {
 					strValue := fmt.Sprintf("%v", value)
 					endpoint = strings.ReplaceAll(endpoint, placeholder, strValue)
-					fmt.Printf("  [优先级2] 替换占位符 %s 为上下文变量值: %s\n", placeholder, strValue)
+					fmt.Printf("  [优先级2] 替换占位符 %s 为上下文变量值: %s\n", placeholder, masked(strValue))
 
 					// 同时添加到路径参数中，以便后续处理
 					pathParams[paramName] = strValue
@@ -570,30 +853,18 @@ func (m *Manager) processVariables(step *yaml.Step) (map[string]string, map[stri
 					fmt.Printf("  [优先级2] 上下文变量中未找到 %s 的值\n", paramName)
 				}
 
-				// 3. 如果还没有替换，尝试使用相似名称的变量
+				// 3. 开启 fuzzy_variables 时尝试相似名称的变量
 				// 例如，将 event_id 转换为 eventId 或 eventID
-				alternativeNames := []string{
-					toCamelCase(paramName),
-					toSnakeCase(paramName),
-				}
-
 				var altFound bool
-				for _, altName := range alternativeNames {
-					if altName == paramName {
-						continue // 跳过相同的名称
-					}
-
-					fmt.Printf("  [优先级3] 尝试相似名称: %s\n", altName)
-
-					if value, exists := m.Context.Variables[altName]; exists {
+				if m.fuzzyVariables() {
+					if value, altName, ok := m.lookupSimilarVariable(paramName); ok {
 						strValue := fmt.Sprintf("%v", value)
 						endpoint = strings.ReplaceAll(endpoint, placeholder, strValue)
-						fmt.Printf("  [优先级3] 替换占位符 %s 为相似名称变量 %s 的值: %s\n", placeholder, altName, strValue)
+						fmt.Printf("  [优先级3] 替换占位符 %s 为相似名称变量 %s 的值: %s\n", placeholder, altName, masked(strValue))
 
 						// 同时添加到路径参数中，以便后续处理
 						pathParams[paramName] = strValue
 						altFound = true
-						break
 					}
 				}
 
@@ -608,7 +879,7 @@ func (m *Manager) processVariables(step *yaml.Step) (map[string]string, map[stri
 					for defName, defValue := range defaultValues {
 						if paramName == defName {
 							endpoint = strings.ReplaceAll(endpoint, placeholder, defValue)
-							fmt.Printf("  [优先级4] 替换占位符 %s 为默认值: %s\n", placeholder, defValue)
+							fmt.Printf("  [优先级4] 替换占位符 %s 为默认值: %s\n", placeholder, masked(defValue))
 
 							// 同时添加到路径参数中，以便后续处理
 							pathParams[paramName] = defValue
@@ -627,295 +898,71 @@ func (m *Manager) processVariables(step *yaml.Step) (map[string]string, map[stri
 		// 如果还有未替换的参数，输出警告
 		if strings.Contains(endpoint, "{") && strings.Contains(endpoint, "}") {
 			fmt.Printf("警告: 端点 %s 仍然包含未替换的参数占位符\n", endpoint)
+			for _, match := range re.FindAllString(endpoint, -1) {
+				m.recordProblem(fmt.Sprintf("路径参数 %s 没有取值", match))
+			}
 		}
 
 		// 更新步骤的端点路径
 		step.Endpoint = endpoint
-		fmt.Printf("处理后的端点: %s\n", endpoint)
+		fmt.Printf("处理后的端点: %s\n", masked(endpoint))
 	}
 
 	// 处理不同类型的 RequestBody
-	fmt.Printf("DEBUG: step.RequestBody type: %T, value: %v\n", step.RequestBody, step.RequestBody)
-	switch body := step.RequestBody.(type) {
-	case string:
-		// 如果是字符串，直接替换变量
-		if body != "" {
-			requestBodyStr = m.replaceVariables(body)
-		}
-	case map[string]interface{}, map[interface{}]interface{}:
-		// 如果是对象，先转成 JSON 字符串
-		jsonBytes, err := json.Marshal(body)
+	fmt.Printf("DEBUG: step.RequestBody type: %T, value: %v\n", step.RequestBody, masked(step.RequestBody))
+	// 在解析后的请求体结构上替换变量，只包含单个变量引用的字段保留变量的原始类型
+	if step.RequestBody != nil && step.RequestBody != "" {
+		body, err := m.renderBody(step.RequestBody)
 		if err != nil {
-			fmt.Printf("警告: 无法将请求体转换为 JSON: %v\n", err)
+			fmt.Printf("警告: 无法将请求体类型 %T 转换为 JSON: %v\n", step.RequestBody, err)
 		} else {
-			jsonStr := string(jsonBytes)
-			fmt.Printf("请求体 JSON 字符串（变量替换前）: %s\n", jsonStr)
-			requestBodyStr = m.replaceVariables(jsonStr)
-			fmt.Printf("请求体 JSON 字符串（变量替换后）: %s\n", requestBodyStr)
-		}
-	case nil:
-		// 如果为空，不需要处理
-		requestBodyStr = ""
-	default:
-		// 其他类型，尝试转成 JSON
-		jsonBytes, err := json.Marshal(body)
-		if err != nil {
-			fmt.Printf("警告: 无法将请求体类型 %T 转换为 JSON: %v\n", body, err)
-		} else {
-			requestBodyStr = m.replaceVariables(string(jsonBytes))
+			requestBodyStr = body
+			fmt.Printf("请求体（变量替换后）: %s\n", masked(requestBodyStr))
 		}
 	}
 
 	return pathParams, queryParams, requestBodyStr
 }
 
-// replaceVariables 替换字符串中的变量
-func (m *Manager) replaceVariables(input string) string {
-	// 先处理 Go 模板语法 {{.variable}}
-	result := input
-
-	// 1. 使用正则表达式匹配 Go 模板语法
-	re := regexp.MustCompile(`{{\s*\.([a-zA-Z0-9_]+)\s*}}`)
-	matches := re.FindAllStringSubmatch(result, -1)
-
-	fmt.Printf("处理请求体中的变量，共找到 %d 个变量\n", len(matches))
-
-	for _, match := range matches {
-		if len(match) > 1 {
-			placeholder := match[0] // {{.varName}}
-			varName := match[1]     // varName
-
-			fmt.Printf("  处理请求体中的变量: %s (占位符: %s)\n", varName, placeholder)
-
-			// 1. 检查上下文变量 - 精确匹配
-			if value, exists := m.Context.Variables[varName]; exists {
-				strValue := fmt.Sprintf("%v", value)
-				result = strings.ReplaceAll(result, placeholder, strValue)
-				fmt.Printf("  [优先级1] 替换请求体中的变量 %s 为上下文变量值: %s\n", placeholder, strValue)
-				continue
-			} else {
-				fmt.Printf("  [优先级1] 上下文变量中未找到精确匹配: %s\n", varName)
-			}
-
-			// 2. 尝试相似名称 - 驼峰和下划线转换
-			alternativeNames := []string{
-				toCamelCase(varName),
-				toSnakeCase(varName),
-			}
-
-			var replaced bool
-			for _, altName := range alternativeNames {
-				if altName == varName {
-					continue // 跳过相同的名称
-				}
-
-				fmt.Printf("  [优先级2] 尝试相似名称: %s\n", altName)
+// processRequestHeaders 处理场景和步骤级别的请求头与基础URL
+// 合并顺序为：场景请求头 < 步骤请求头（全局请求头由 APIClient 负责），基础URL同理
+func (m *Manager) processRequestHeaders(scenario *yaml.Scenario, step *yaml.Step) (map[string]string, string) {
+	headers := make(map[string]string)
 
-				if value, exists := m.Context.Variables[altName]; exists {
-					strValue := fmt.Sprintf("%v", value)
-					result = strings.ReplaceAll(result, placeholder, strValue)
-					fmt.Printf("  [优先级2] 替换请求体中的变量 %s 为相似名称变量 %s 的值: %s\n", placeholder, altName, strValue)
-					replaced = true
-					break
-				}
-			}
-
-			// 3. 尝试全局变量中的键名 - 不区分大小写
-			if !replaced {
-				varNameLower := strings.ToLower(varName)
-				for k, v := range m.Context.Variables {
-					if strings.ToLower(k) == varNameLower {
-						strValue := fmt.Sprintf("%v", v)
-						result = strings.ReplaceAll(result, placeholder, strValue)
-						fmt.Printf("  [优先级3] 替换请求体中的变量 %s 为不区分大小写的变量 %s 的值: %s\n", placeholder, k, strValue)
-						replaced = true
-						break
-					}
-				}
-			}
-
-			// 4. 使用默认值
-			if !replaced {
-				// 从配置中获取默认值
-				defaultValues := m.getDefaultValues()
-
-				// 对于常见的参数名称，使用默认值
-				defaultFound := false
-				for defName, defValue := range defaultValues {
-					if varName == defName {
-						result = strings.ReplaceAll(result, placeholder, defValue)
-						fmt.Printf("  [优先级4] 替换请求体中的变量 %s 为默认值: %s\n", placeholder, defValue)
-						defaultFound = true
-						break
-					}
-				}
-
-				if !defaultFound {
-					fmt.Printf("  警告: 无法替换变量 %s，将保持原样\n", placeholder)
-				}
-			}
+	// 先合并场景请求头，再由步骤请求头覆盖
+	for _, source := range []map[string]string{scenario.Headers, step.Headers} {
+		for key, value := range source {
+			headers[key] = m.render(value)
+			fmt.Printf("请求头: %s = %s\n", key, masked(headers[key]))
 		}
 	}
 
-	// 2. 处理 {variable} 格式的变量
-	re = regexp.MustCompile(`\{([^{}]+)\}`)
-	matches = re.FindAllStringSubmatch(result, -1)
-
-	for _, match := range matches {
-		if len(match) > 1 {
-			placeholder := match[0] // {varName}
-			varName := match[1]     // varName
-
-			// 检查上下文变量
-			if value, exists := m.Context.Variables[varName]; exists {
-				strValue := fmt.Sprintf("%v", value)
-				result = strings.ReplaceAll(result, placeholder, strValue)
-				fmt.Printf("  替换占位符 %s 为上下文变量值: %s\n", placeholder, strValue)
-				continue
-			}
-
-			// 尝试相似名称
-			alternativeNames := []string{
-				toCamelCase(varName),
-				toSnakeCase(varName),
-			}
-
-			var replaced bool
-			for _, altName := range alternativeNames {
-				if altName == varName {
-					continue // 跳过相同的名称
-				}
-
-				if value, exists := m.Context.Variables[altName]; exists {
-					strValue := fmt.Sprintf("%v", value)
-					result = strings.ReplaceAll(result, placeholder, strValue)
-					fmt.Printf("  替换占位符 %s 为相似名称变量 %s 的值: %s\n", placeholder, altName, strValue)
-					replaced = true
-					break
-				}
-			}
-
-			// 使用默认值
-			if !replaced {
-				// 从配置中获取默认值
-				defaultValues := m.getDefaultValues()
-
-				for defName, defValue := range defaultValues {
-					if varName == defName {
-						result = strings.ReplaceAll(result, placeholder, defValue)
-						fmt.Printf("  替换占位符 %s 为默认值: %s\n", placeholder, defValue)
-						break
-					}
-				}
-			}
-		}
+	// 步骤基础URL优先于场景基础URL
+	baseURL := scenario.BaseURL
+	if step.BaseURL != "" {
+		baseURL = step.BaseURL
 	}
-
-	return result
-}
-
-// extractVariables 从响应中提取变量
-func (m *Manager) extractVariables(extractors map[string]string, responseBody []byte) {
-	// 检查响应是否是有效的 JSON
-	if !json.Valid(responseBody) {
-		fmt.Println("响应不是有效的 JSON，无法提取变量")
-		return
+	if baseURL != "" {
+		baseURL = m.render(baseURL)
 	}
 
-	// 打印响应体结构以便调试
-	fmt.Println("响应体结构:", string(responseBody))
-
-	// 使用 gjson 提取变量
-	for name, path := range extractors {
-		// 打印当前尝试提取的路径
-		fmt.Printf("尝试从路径 %s 提取变量 %s\n", path, name)
-
-		// 标准化路径格式（确保路径以 $ 开头）
-		if !strings.HasPrefix(path, "$") {
-			path = "$" + path
-		}
-
-		// 替换 JSON 路径中的变量（支持 {{.var}} 格式）
-		path = m.replaceGoTemplateVars(path)
-		path = m.replaceVariables(path)
-
-		// 使用 gjson 提取变量
-		result := gjson.GetBytes(responseBody, path)
-
-		// 打印提取结果
-		fmt.Printf("  路径 %s 的提取结果存在: %v\n", path, result.Exists())
-
-		if result.Exists() {
-			m.Context.Variables[name] = result.Value()
-			fmt.Printf("  成功提取变量: %s = %v\n", name, result.Value())
-		} else {
-			// 如果路径不存在，尝试不同的路径格式
-			// 尝试去除路径中的 $. 前缀
-			cleanPath := strings.TrimPrefix(path, "$.")
-			result = gjson.GetBytes(responseBody, cleanPath)
-
-			fmt.Printf("  尝试去除 $. 前缀后的路径 %s 的提取结果存在: %v\n", cleanPath, result.Exists())
-
-			if result.Exists() {
-				m.Context.Variables[name] = result.Value()
-				fmt.Printf("  成功提取变量: %s = %v (使用去除前缀的路径: %s)\n", name, result.Value(), cleanPath)
-			} else {
-				// 如果还是失败，尝试直接使用数组索引
-				// 例如，如果路径是 $.tables[0].id，尝试 tables.0.id
-				arrayPath := regexp.MustCompile(`\[(\d+)\]`).ReplaceAllString(cleanPath, ".$1")
-				result = gjson.GetBytes(responseBody, arrayPath)
-
-				fmt.Printf("  尝试使用点表示法的路径 %s 的提取结果存在: %v\n", arrayPath, result.Exists())
-
-				if result.Exists() {
-					m.Context.Variables[name] = result.Value()
-					fmt.Printf("  成功提取变量: %s = %v (使用点表示法的路径: %s)\n", name, result.Value(), arrayPath)
-				} else {
-					// 如果还是失败，尝试直接获取第一个元素
-					// 从路径中提取数组名称
-					parts := strings.Split(cleanPath, ".")
-					if len(parts) > 0 {
-						arrayName := parts[0]
-						// 尝试获取数组的第一个元素
-						arrayFirstPath := arrayName + ".0.id"
-						result = gjson.GetBytes(responseBody, arrayFirstPath)
-
-						fmt.Printf("  尝试获取数组第一个元素的路径 %s 的提取结果存在: %v\n", arrayFirstPath, result.Exists())
-
-						if result.Exists() {
-							m.Context.Variables[name] = result.Value()
-							fmt.Printf("  成功提取变量: %s = %v (使用数组第一个元素的路径: %s)\n", name, result.Value(), arrayFirstPath)
-						} else {
-							// 所有提取尝试都失败，设置变量为空字符串（用于清理步骤）
-							// 这样后续步骤可以使用空字符串作为占位符，不会因为变量未设置而失败
-							m.Context.Variables[name] = ""
-							fmt.Printf("  警告: 无法从路径 %s 提取变量 %s，设置为空字符串（用于清理步骤）\n", path, name)
-						}
-					} else {
-						// 所有提取尝试都失败，设置变量为空字符串（用于清理步骤）
-						m.Context.Variables[name] = ""
-						fmt.Printf("  警告: 无法从路径 %s 提取变量 %s，设置为空字符串（用于清理步骤）\n", path, name)
-					}
-				}
-			}
-		}
-	}
+	return headers, baseURL
 }
 
 // GetVariable 获取变量值
 func (m *Manager) GetVariable(name string) (interface{}, bool) {
-	value, exists := m.Context.Variables[name]
+	value, exists := m.Context.GetVariable(name)
 	return value, exists
 }
 
 // SetVariable 设置变量值
 func (m *Manager) SetVariable(name string, value interface{}) {
-	m.Context.Variables[name] = value
+	m.Context.SetVariable(name, value)
 }
 
 // GetStepResult 获取步骤结果
 func (m *Manager) GetStepResult(stepName string) (*types.EndpointTestResult, bool) {
-	result, exists := m.Context.Results[stepName]
+	result, exists := m.Context.GetResult(stepName)
 	return result, exists
 }
 
@@ -956,7 +1003,7 @@ func toSnakeCase(s string) string {
 // getDefaultValues 从配置中获取默认值
 func (m *Manager) getDefaultValues() map[string]string {
 	// 首先检查上下文中是否已经有默认值配置
-	if defaultConfig, exists := m.Context.Variables["default_values"]; exists {
+	if defaultConfig, exists := m.Context.GetVariable("default_values"); exists {
 		if defaultMap, ok := defaultConfig.(map[string]interface{}); ok {
 			// 将 map[string]interface{} 转换为 map[string]string
 			result := make(map[string]string)
@@ -967,6 +1014,11 @@ func (m *Manager) getDefaultValues() map[string]string {
 		}
 	}
 
+	// 严格模式下不使用通用的默认值
+	if m.strict() {
+		return nil
+	}
+
 	// 如果没有配置，使用通用的默认值
 	return map[string]string{
 		"id":     "1",
@@ -976,93 +1028,3 @@ func (m *Manager) getDefaultValues() map[string]string {
 		"token":  "test-token",
 	}
 }
-
-// replaceGoTemplateVars 替换Go模板语法变量
-func (m *Manager) replaceGoTemplateVars(input string) string {
-	// 匹配 Go 模板语法 {{.varName}}
-	re := regexp.MustCompile(`{{\s*\.([a-zA-Z0-9_]+)\s*}}`)
-	matches := re.FindAllStringSubmatch(input, -1)
-
-	result := input
-
-	fmt.Printf("开始处理Go模板变量，共找到 %d 个变量\n", len(matches))
-
-	for _, match := range matches {
-		if len(match) > 1 {
-			placeholder := match[0] // {{.varName}}
-			varName := match[1]     // varName
-
-			fmt.Printf("  处理变量: %s (占位符: %s)\n", varName, placeholder)
-
-			// 1. 检查上下文变量 - 精确匹配
-			if value, exists := m.Context.Variables[varName]; exists {
-				strValue := fmt.Sprintf("%v", value)
-				result = strings.ReplaceAll(result, placeholder, strValue)
-				fmt.Printf("  [优先级1] 替换Go模板变量 %s 为上下文变量值: %s\n", placeholder, strValue)
-				continue
-			} else {
-				fmt.Printf("  [优先级1] 上下文变量中未找到精确匹配: %s\n", varName)
-			}
-
-			// 2. 尝试相似名称 - 驼峰和下划线转换
-			alternativeNames := []string{
-				toCamelCase(varName),
-				toSnakeCase(varName),
-			}
-
-			var replaced bool
-			for _, altName := range alternativeNames {
-				if altName == varName {
-					continue // 跳过相同的名称
-				}
-
-				fmt.Printf("  [优先级2] 尝试相似名称: %s\n", altName)
-
-				if value, exists := m.Context.Variables[altName]; exists {
-					strValue := fmt.Sprintf("%v", value)
-					result = strings.ReplaceAll(result, placeholder, strValue)
-					fmt.Printf("  [优先级2] 替换Go模板变量 %s 为相似名称变量 %s 的值: %s\n", placeholder, altName, strValue)
-					replaced = true
-					break
-				}
-			}
-
-			// 3. 尝试全局变量中的键名 - 不区分大小写
-			if !replaced {
-				varNameLower := strings.ToLower(varName)
-				for k, v := range m.Context.Variables {
-					if strings.ToLower(k) == varNameLower {
-						strValue := fmt.Sprintf("%v", v)
-						result = strings.ReplaceAll(result, placeholder, strValue)
-						fmt.Printf("  [优先级3] 替换Go模板变量 %s 为不区分大小写的变量 %s 的值: %s\n", placeholder, k, strValue)
-						replaced = true
-						break
-					}
-				}
-			}
-
-			// 4. 使用默认值
-			if !replaced {
-				// 从配置中获取默认值
-				defaultValues := m.getDefaultValues()
-
-				// 使用配置中的默认值，不添加特定业务领域的硬编码映射
-				defaultFound := false
-				for defName, defValue := range defaultValues {
-					if varName == defName {
-						result = strings.ReplaceAll(result, placeholder, defValue)
-						fmt.Printf("  [优先级4] 替换Go模板变量 %s 为默认值: %s\n", placeholder, defValue)
-						defaultFound = true
-						break
-					}
-				}
-
-				if !defaultFound {
-					fmt.Printf("  警告: 无法替换变量 %s，将保持原样\n", placeholder)
-				}
-			}
-		}
-	}
-
-	return result
-}
@@ -0,0 +1,114 @@
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/parser"
+	"github.com/gaoyong06/api-tester/internal/types"
+	"github.com/gaoyong06/api-tester/pkg/client"
+)
+
+// recordingServer 记录收到的请求；responses 中配置了路径的返回对应的 JSON，其他路径返回请求的路径和查询参数
+type recordingServer struct {
+	*httptest.Server
+	responses map[string]string
+	// 路径对应的前几次请求返回的错误状态码，0 表示直接断开连接，用于测试自动重试
+	failures map[string][]int
+
+	mu       sync.Mutex
+	requests []*url.URL
+}
+
+func newRecordingServer(t *testing.T, responses map[string]string) *recordingServer {
+	s := &recordingServer{responses: responses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL)
+		var failure *int
+		if statuses := s.failures[r.URL.Path]; len(statuses) > 0 {
+			failure = &statuses[0]
+			s.failures[r.URL.Path] = statuses[1:]
+		}
+		s.mu.Unlock()
+
+		if failure != nil {
+			if *failure == 0 {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			w.WriteHeader(*failure)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if body, exists := s.responses[r.URL.Path]; exists {
+			w.Write([]byte(body))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"path": r.URL.Path, "query": r.URL.Query()})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// reset 清除记录的请求
+func (s *recordingServer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// paths 返回收到的请求的路径，按字母顺序排列
+func (s *recordingServer) paths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths := make([]string, 0, len(s.requests))
+	for _, u := range s.requests {
+		paths = append(paths, u.Path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// query 返回路径为 path 的请求的查询参数 name 的值，按请求顺序排列
+func (s *recordingServer) query(path, name string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var values []string
+	for _, u := range s.requests {
+		if u.Path == path {
+			values = append(values, u.Query().Get(name))
+		}
+	}
+	return values
+}
+
+// runTestScenarios 使用测试服务器运行配置中的所有场景
+func runTestScenarios(t *testing.T, server *recordingServer, config *yaml.Config) []*types.EndpointTestResult {
+	t.Helper()
+	scenarios := make([]*yaml.Scenario, len(config.Scenarios))
+	for i := range config.Scenarios {
+		scenarios[i] = &config.Scenarios[i]
+	}
+
+	m := NewManager(scenarios, &parser.APIDefinition{}, client.NewAPIClient(server.URL, nil, 5, false, nil), config)
+	results, err := m.RunAllScenarios(context.Background())
+	if err != nil {
+		t.Fatalf("RunAllScenarios: %v", err)
+	}
+	return results
+}
+
+// getStep 创建发送 GET 请求的步骤
+func getStep(name, endpoint string, query map[string]string) yaml.Step {
+	return yaml.Step{Name: name, Endpoint: endpoint, Method: "GET", QueryParams: query}
+}
@@ -0,0 +1,111 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gaoyong06/api-tester/internal/config/yaml"
+	"github.com/gaoyong06/api-tester/internal/types"
+)
+
+// notRunPrefix 因提前停止而未执行的步骤的跳过原因前缀
+const notRunPrefix = "未执行: "
+
+// stopState 停止状态，记录第一个停止原因，可在多个 goroutine 间共享
+type stopState struct {
+	mu     sync.Mutex
+	reason string
+}
+
+// set 设置停止原因，已经停止时保留原来的原因
+func (s *stopState) set(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reason == "" {
+		s.reason = reason
+	}
+}
+
+// get 获取停止原因，未停止时返回空字符串
+func (s *stopState) get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reason
+}
+
+// stopReason 返回不再执行新步骤的原因，应继续执行时返回空字符串
+// 清理阶段（teardown / after_all）总是继续执行
+func (m *Manager) stopReason() string {
+	if m.cleanup {
+		return ""
+	}
+	if reason := m.runStopReason(); reason != "" {
+		return reason
+	}
+	if m.scenarioStop != nil {
+		return m.scenarioStop.get()
+	}
+	return ""
+}
+
+// runStopReason 返回整个运行（或当前场景的 context）停止的原因：
+// 失败数达到上限、运行被中断、超过截止时间或场景超时
+func (m *Manager) runStopReason() string {
+	if reason := m.stop.get(); reason != "" {
+		return reason
+	}
+	if m.ctx.Err() == nil {
+		return ""
+	}
+	if cause := context.Cause(m.ctx); !errors.Is(cause, context.Canceled) {
+		return cause.Error()
+	}
+	return "运行被中断"
+}
+
+// sleep 等待指定的时间，运行的 context 被取消时提前返回
+func (m *Manager) sleep(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-m.ctx.Done():
+	}
+}
+
+// recordOutcome 记录步骤结果，失败数达到上限或场景设置了 stop_on_failure 时停止执行后续步骤
+func (m *Manager) recordOutcome(scenario *yaml.Scenario, result *types.EndpointTestResult) {
+	if result.Validation.Skipped || result.Validation.Passed {
+		return
+	}
+
+	failures := atomic.AddInt32(m.failures, 1)
+	if m.MaxFailures > 0 && int(failures) >= m.MaxFailures {
+		if m.stop.get() == "" {
+			fmt.Printf("失败数达到上限 %d，停止执行剩余的步骤和场景\n", m.MaxFailures)
+		}
+		m.stop.set(fmt.Sprintf("失败数达到上限 %d", m.MaxFailures))
+	}
+
+	m.stopScenario(scenario, result.Step)
+}
+
+// stopScenario 场景设置了 stop_on_failure 时，停止执行场景中剩余的步骤
+func (m *Manager) stopScenario(scenario *yaml.Scenario, failedStep string) {
+	if scenario.StopOnFailure && m.scenarioStop != nil && !m.cleanup {
+		m.scenarioStop.set(fmt.Sprintf("步骤 %s 失败 (stop_on_failure)", failedStep))
+	}
+}
+
+// failStep 创建未能执行的步骤的失败结果并记录
+func (m *Manager) failStep(scenario *yaml.Scenario, step *yaml.Step, reason string) *types.EndpointTestResult {
+	fmt.Printf("步骤失败: %s - %s\n", step.Name, reason)
+	result := m.failedResult(scenario, step, reason)
+	m.recordOutcome(scenario, result)
+	return result
+}
@@ -10,6 +10,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/gaoyong06/api-tester/pkg/utils"
 	"github.com/google/uuid"
 )
 
@@ -58,6 +59,9 @@ func (tp *Processor) RegisterFunctions() {
 			return string(b)
 		},
 
+		// 环境变量函数，变量名像密钥时其值会在日志和报告中屏蔽
+		"env": utils.Getenv,
+
 		// 编码函数
 		"base64": func(s string) string {
 			return base64.StdEncoding.EncodeToString([]byte(s))
@@ -155,6 +159,22 @@ func (tp *Processor) Process(input string) (string, error) {
 	return buf.String(), nil
 }
 
+// Render 使用指定的变量渲染模板，不读取也不修改处理器的变量，可以并发调用
+// 模板引用不存在的变量时返回错误
+func (tp *Processor) Render(input string, variables map[string]interface{}) (string, error) {
+	tmpl, err := template.New("").Funcs(tp.Functions).Option("missingkey=error").Parse(input)
+	if err != nil {
+		return "", fmt.Errorf("无法解析模板: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return "", fmt.Errorf("无法执行模板: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
 // SetVariable 设置变量
 func (tp *Processor) SetVariable(name string, value interface{}) {
 	tp.Variables[name] = value
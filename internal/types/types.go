@@ -11,6 +11,10 @@ import (
 type ValidationResult struct {
 	// 是否通过验证
 	Passed bool
+	// 是否跳过（未执行）
+	Skipped bool
+	// 跳过原因
+	SkipReason string
 	// 失败原因
 	FailureReason string
 	// 预期状态码
@@ -21,10 +25,54 @@ type ValidationResult struct {
 	ResponseTime int64
 	// 响应体
 	ResponseBody string
+	// 断言结果列表（场景模式下每条断言一项）
+	Assertions []AssertionResult
+	// 请求尝试记录（轮询或自动重试时每次请求一项）
+	Attempts []AttemptResult
+	// 自动重试的次数（不含轮询）
+	Retries int
+	// 是否不稳定：自动重试后才通过
+	Flaky bool
+}
+
+// AttemptResult 表示一次请求尝试的结果
+type AttemptResult struct {
+	// 第几次尝试（从 1 开始）
+	Attempt int
+	// 实际状态码
+	StatusCode int
+	// 响应时间（毫秒）
+	ResponseTime int64
+	// 是否满足停止条件
+	Passed bool
+	// 未满足条件的原因
+	FailureReason string
+	// 尝试时间
+	Time time.Time
+}
+
+// AssertionResult 表示单条断言的结果
+type AssertionResult struct {
+	// 断言目标（如 status、$.data.id、header:Location）
+	Path string
+	// 比较操作符（如 eq、>=、matches）
+	Operator string
+	// 期望值
+	Expected string
+	// 实际值
+	Actual string
+	// 是否通过
+	Passed bool
+	// 失败信息
+	Message string
 }
 
 // EndpointTestResult 表示单个端点的测试结果
 type EndpointTestResult struct {
+	// 场景名称（场景模式）
+	Scenario string
+	// 步骤名称（场景模式）
+	Step string
 	// 端点信息（使用指针避免循环导入）
 	Endpoint interface{}
 	// 验证结果
@@ -41,12 +89,29 @@ type TestResult struct {
 	Passed int
 	// 失败测试数
 	Failed int
+	// 跳过测试数
+	Skipped int
+	// 不稳定（重试后才通过）的测试数，包含在通过数中
+	Flaky int
+	// 测试环境名称（--env 选择的环境）
+	Environment string
+	// 运行没有全部完成的原因（如超过截止时间、被中断），为空表示全部完成
+	StopReason string
 	// 测试报告路径
 	ReportPath string
 	// 测试结果详情
 	Results []*EndpointTestResult `json:"results"`
 }
 
+// FailRate 返回失败率（百分比），跳过的测试不计入；没有执行任何测试时返回 0
+func (r *TestResult) FailRate() float64 {
+	executed := r.Passed + r.Failed
+	if executed == 0 {
+		return 0
+	}
+	return float64(r.Failed) * 100 / float64(executed)
+}
+
 // LoadTestResultsFromFile 从文件加载测试结果
 func LoadTestResultsFromFile(filePath string) (*TestResult, error) {
 	// 读取文件内容
@@ -0,0 +1,247 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaoyong06/api-tester/pkg/utils"
+)
+
+// 认证类型
+const (
+	AuthBasic                   = "basic"
+	AuthBearer                  = "bearer"
+	AuthAPIKey                  = "api_key"
+	AuthOAuth2ClientCredentials = "oauth2_client_credentials"
+	AuthOAuth2Password          = "oauth2_password"
+)
+
+// tokenRefreshMargin 访问令牌在过期前多久刷新，有效期很短时最多提前有效期的一半
+const tokenRefreshMargin = 30 * time.Second
+
+// Auth 表示请求的认证方式
+type Auth struct {
+	// 认证类型
+	Type string
+	// 用户名和密码（basic、oauth2_password）
+	Username string
+	Password string
+	// 令牌（bearer）
+	Token string
+	// API Key 的名称、值和位置（api_key），位置为 header（默认）、query 或 cookie
+	Name  string
+	Value string
+	In    string
+	// 获取访问令牌的地址（oauth2_*）
+	TokenURL string
+	// 客户端凭据（oauth2_*）
+	ClientID     string
+	ClientSecret string
+	// 客户端凭据的发送方式：header（默认，HTTP Basic 认证）或 body（表单字段）
+	ClientAuth string
+	// 申请的权限范围（oauth2_*）
+	Scopes []string
+}
+
+// oauthToken 缓存的 OAuth2 访问令牌
+type oauthToken struct {
+	accessToken  string
+	refreshToken string
+	// 需要刷新的时间，为零值时在整个运行期间有效
+	refreshAt time.Time
+}
+
+// tokenEntry 一组 OAuth2 凭据对应的令牌，锁保证同一组凭据同时只获取一次令牌
+type tokenEntry struct {
+	mu    sync.Mutex
+	token *oauthToken
+}
+
+// tokenCache 按凭据缓存 OAuth2 访问令牌，所有场景共享
+type tokenCache struct {
+	mu      sync.Mutex
+	entries map[string]*tokenEntry
+}
+
+// newTokenCache 创建令牌缓存
+func newTokenCache() *tokenCache {
+	return &tokenCache{entries: make(map[string]*tokenEntry)}
+}
+
+// entry 返回凭据对应的缓存项，不存在时创建
+func (tc *tokenCache) entry(key string) *tokenEntry {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	entry, exists := tc.entries[key]
+	if !exists {
+		entry = &tokenEntry{}
+		tc.entries[key] = entry
+	}
+	return entry
+}
+
+// applyAuth 为请求添加认证信息，OAuth2 认证会按需获取或刷新访问令牌
+func (c *APIClient) applyAuth(ctx context.Context, req *http.Request, auth *Auth) error {
+	switch auth.Type {
+	case AuthBasic:
+		req.SetBasicAuth(auth.Username, auth.Password)
+		// 编码后的凭据同样在日志中屏蔽
+		utils.RegisterSecret(strings.TrimPrefix(req.Header.Get("Authorization"), "Basic "))
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case AuthAPIKey:
+		switch auth.In {
+		case "", "header":
+			req.Header.Set(auth.Name, auth.Value)
+		case "query":
+			if req.URL.RawQuery != "" {
+				req.URL.RawQuery += "&"
+			}
+			req.URL.RawQuery += url.QueryEscape(auth.Name) + "=" + url.QueryEscape(auth.Value)
+		case "cookie":
+			req.AddCookie(&http.Cookie{Name: auth.Name, Value: auth.Value})
+		default:
+			return fmt.Errorf("api_key 认证不支持的位置: %s", auth.In)
+		}
+	case AuthOAuth2ClientCredentials, AuthOAuth2Password:
+		token, err := c.accessToken(ctx, auth)
+		if err != nil {
+			return fmt.Errorf("获取 OAuth2 访问令牌失败: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	default:
+		return fmt.Errorf("不支持的认证类型: %s", auth.Type)
+	}
+	return nil
+}
+
+// accessToken 返回缓存的访问令牌，令牌即将过期时优先用 refresh_token 刷新，刷新失败时重新获取
+func (c *APIClient) accessToken(ctx context.Context, auth *Auth) (string, error) {
+	entry := c.tokens.entry(auth.cacheKey())
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	cached := entry.token
+	if cached != nil && (cached.refreshAt.IsZero() || time.Now().Before(cached.refreshAt)) {
+		return cached.accessToken, nil
+	}
+
+	var token *oauthToken
+	if cached != nil && cached.refreshToken != "" {
+		var err error
+		token, err = c.requestToken(ctx, auth, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {cached.refreshToken},
+		})
+		if err != nil {
+			fmt.Printf("警告: 刷新 OAuth2 访问令牌失败，重新获取: %v\n", err)
+		}
+	}
+
+	if token == nil {
+		form := url.Values{}
+		if auth.Type == AuthOAuth2Password {
+			form.Set("grant_type", "password")
+			form.Set("username", auth.Username)
+			form.Set("password", auth.Password)
+		} else {
+			form.Set("grant_type", "client_credentials")
+		}
+		if len(auth.Scopes) > 0 {
+			form.Set("scope", strings.Join(auth.Scopes, " "))
+		}
+
+		var err error
+		if token, err = c.requestToken(ctx, auth, form); err != nil {
+			return "", err
+		}
+	}
+
+	// 刷新时服务端没有返回新的 refresh_token 则继续使用原来的
+	if token.refreshToken == "" && cached != nil {
+		token.refreshToken = cached.refreshToken
+	}
+	entry.token = token
+	return token.accessToken, nil
+}
+
+// requestToken 向令牌地址发送表单请求，解析返回的访问令牌
+func (c *APIClient) requestToken(ctx context.Context, auth *Auth, form url.Values) (*oauthToken, error) {
+	if auth.ClientAuth == "body" {
+		form.Set("client_id", auth.ClientID)
+		if auth.ClientSecret != "" {
+			form.Set("client_secret", auth.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("创建令牌请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if auth.ClientAuth != "body" && auth.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+	}
+
+	obtainedAt := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求 %s 失败: %v", auth.TokenURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取令牌响应失败: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s 返回状态码 %d: %s", auth.TokenURL, resp.StatusCode, utils.MaskSecrets(string(body)))
+	}
+
+	var result struct {
+		AccessToken  string      `json:"access_token"`
+		RefreshToken string      `json:"refresh_token"`
+		ExpiresIn    json.Number `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("无法解析令牌响应: %v", err)
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("令牌响应中没有 access_token")
+	}
+
+	// 访问令牌在日志和报告中屏蔽
+	utils.RegisterSecret(result.AccessToken)
+	utils.RegisterSecret(result.RefreshToken)
+
+	token := &oauthToken{accessToken: result.AccessToken, refreshToken: result.RefreshToken}
+	if seconds, err := result.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		lifetime := time.Duration(seconds) * time.Second
+		margin := tokenRefreshMargin
+		if margin > lifetime/2 {
+			margin = lifetime / 2
+		}
+		token.refreshAt = obtainedAt.Add(lifetime - margin)
+	}
+
+	if c.verbose {
+		fmt.Printf("获取 OAuth2 访问令牌: %s (grant_type=%s, expires_in=%s)\n", auth.TokenURL, form.Get("grant_type"), result.ExpiresIn)
+	}
+	return token, nil
+}
+
+// cacheKey 返回区分不同凭据的缓存键
+func (a *Auth) cacheKey() string {
+	return strings.Join([]string{
+		a.Type, a.TokenURL, a.ClientID, a.ClientSecret, a.ClientAuth,
+		a.Username, a.Password, strings.Join(a.Scopes, " "),
+	}, "\x00")
+}
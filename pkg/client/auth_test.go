@@ -0,0 +1,270 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer 测试用的令牌地址，记录收到的表单；handler 根据表单返回状态码和响应体
+type tokenServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	forms []url.Values
+	users []string
+}
+
+func newTokenServer(t *testing.T, handler func(form url.Values, count int) (int, string)) *tokenServer {
+	s := &tokenServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm: %v", err)
+		}
+		user, _, _ := r.BasicAuth()
+
+		s.mu.Lock()
+		s.forms = append(s.forms, r.PostForm)
+		s.users = append(s.users, user)
+		count := len(s.forms)
+		s.mu.Unlock()
+
+		status, body := handler(r.PostForm, count)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// grants 返回每次令牌请求的 grant_type
+func (s *tokenServer) grants() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	grants := make([]string, 0, len(s.forms))
+	for _, form := range s.forms {
+		grants = append(grants, form.Get("grant_type"))
+	}
+	return grants
+}
+
+// expire 使缓存的令牌立即需要刷新
+func expire(c *APIClient, auth *Auth) {
+	entry := c.tokens.entry(auth.cacheKey())
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	entry.token.refreshAt = time.Now().Add(-time.Second)
+}
+
+func TestAccessTokenCache(t *testing.T) {
+	server := newTokenServer(t, func(form url.Values, count int) (int, string) {
+		return http.StatusOK, fmt.Sprintf(`{"access_token": "token-%d", "expires_in": 3600}`, count)
+	})
+	c := NewAPIClient("http://example.com", nil, 5, false, nil)
+	auth := &Auth{Type: AuthOAuth2ClientCredentials, TokenURL: server.URL, ClientID: "app", ClientSecret: "secret", Scopes: []string{"read", "write"}}
+
+	// 并发请求同一组凭据时只获取一次令牌
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := c.accessToken(context.Background(), auth)
+			if err != nil {
+				t.Errorf("accessToken: %v", err)
+			}
+			tokens[i] = token
+		}(i)
+	}
+	wg.Wait()
+
+	for i, token := range tokens {
+		if token != "token-1" {
+			t.Errorf("call %d got %q, want token-1", i, token)
+		}
+	}
+	if grants := server.grants(); len(grants) != 1 || grants[0] != "client_credentials" {
+		t.Fatalf("token requests = %v, want one client_credentials request", grants)
+	}
+	if form := server.forms[0]; form.Get("scope") != "read write" || form.Get("client_id") != "" {
+		t.Errorf("form = %v, want scope without client_id", form)
+	}
+	if server.users[0] != "app" {
+		t.Errorf("basic auth user = %q, want app", server.users[0])
+	}
+
+	// 不同的凭据使用各自的令牌
+	other := *auth
+	other.Scopes = []string{"read"}
+	token, err := c.accessToken(context.Background(), &other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-2" {
+		t.Errorf("token for other scopes = %q, want token-2", token)
+	}
+	if token, _ := c.accessToken(context.Background(), auth); token != "token-1" {
+		t.Errorf("token for original scopes = %q, want token-1", token)
+	}
+}
+
+func TestAccessTokenRefreshAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn string
+		want      time.Duration
+	}{
+		{"long lifetime refreshes 30s early", `3600`, time.Hour - tokenRefreshMargin},
+		{"short lifetime refreshes at half", `10`, 5 * time.Second},
+		{"expires_in as string", `"120"`, 90 * time.Second},
+		{"no expires_in", `null`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTokenServer(t, func(form url.Values, count int) (int, string) {
+				return http.StatusOK, `{"access_token": "token", "expires_in": ` + tt.expiresIn + `}`
+			})
+			c := NewAPIClient("http://example.com", nil, 5, false, nil)
+			auth := &Auth{Type: AuthOAuth2ClientCredentials, TokenURL: server.URL, ClientID: "app"}
+
+			before := time.Now()
+			if _, err := c.accessToken(context.Background(), auth); err != nil {
+				t.Fatal(err)
+			}
+			after := time.Now()
+
+			refreshAt := c.tokens.entry(auth.cacheKey()).token.refreshAt
+			if tt.want == 0 {
+				if !refreshAt.IsZero() {
+					t.Errorf("refreshAt = %v, want zero", refreshAt)
+				}
+				return
+			}
+			if refreshAt.Before(before.Add(tt.want)) || refreshAt.After(after.Add(tt.want)) {
+				t.Errorf("refreshAt = %v after the request, want %v", refreshAt.Sub(before), tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessTokenRefresh(t *testing.T) {
+	server := newTokenServer(t, func(form url.Values, count int) (int, string) {
+		switch form.Get("grant_type") {
+		case "password":
+			return http.StatusOK, `{"access_token": "access-1", "refresh_token": "refresh-1", "expires_in": 3600}`
+		case "refresh_token":
+			if form.Get("refresh_token") != "refresh-1" {
+				return http.StatusBadRequest, `{"error": "invalid_grant"}`
+			}
+			// 刷新时不返回新的 refresh_token
+			return http.StatusOK, fmt.Sprintf(`{"access_token": "access-%d", "expires_in": 3600}`, count)
+		}
+		return http.StatusBadRequest, `{"error": "unsupported_grant_type"}`
+	})
+	c := NewAPIClient("http://example.com", nil, 5, false, nil)
+	auth := &Auth{Type: AuthOAuth2Password, TokenURL: server.URL, ClientID: "app", ClientAuth: "body", Username: "alice", Password: "pw"}
+
+	token, err := c.accessToken(context.Background(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "access-1" {
+		t.Fatalf("token = %q, want access-1", token)
+	}
+	if form := server.forms[0]; form.Get("username") != "alice" || form.Get("password") != "pw" || form.Get("client_id") != "app" {
+		t.Errorf("password grant form = %v", form)
+	}
+
+	// 即将过期时使用 refresh_token 刷新，服务端没有返回新的 refresh_token 时继续使用原来的
+	for _, want := range []string{"access-2", "access-3"} {
+		expire(c, auth)
+		token, err := c.accessToken(context.Background(), auth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != want {
+			t.Errorf("refreshed token = %q, want %q", token, want)
+		}
+	}
+
+	want := []string{"password", "refresh_token", "refresh_token"}
+	if grants := server.grants(); fmt.Sprint(grants) != fmt.Sprint(want) {
+		t.Errorf("token requests = %v, want %v", grants, want)
+	}
+}
+
+func TestAccessTokenRefreshFailure(t *testing.T) {
+	server := newTokenServer(t, func(form url.Values, count int) (int, string) {
+		if form.Get("grant_type") == "refresh_token" {
+			return http.StatusBadRequest, `{"error": "invalid_grant"}`
+		}
+		return http.StatusOK, fmt.Sprintf(`{"access_token": "access-%d", "refresh_token": "refresh-%d", "expires_in": 3600}`, count, count)
+	})
+	c := NewAPIClient("http://example.com", nil, 5, false, nil)
+	auth := &Auth{Type: AuthOAuth2ClientCredentials, TokenURL: server.URL, ClientID: "app"}
+
+	if _, err := c.accessToken(context.Background(), auth); err != nil {
+		t.Fatal(err)
+	}
+
+	// 刷新失败时重新获取令牌
+	expire(c, auth)
+	token, err := c.accessToken(context.Background(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "access-3" {
+		t.Errorf("token = %q, want access-3", token)
+	}
+
+	want := []string{"client_credentials", "refresh_token", "client_credentials"}
+	if grants := server.grants(); fmt.Sprint(grants) != fmt.Sprint(want) {
+		t.Errorf("token requests = %v, want %v", grants, want)
+	}
+	if got := c.tokens.entry(auth.cacheKey()).token.refreshToken; got != "refresh-3" {
+		t.Errorf("refresh token = %q, want refresh-3", got)
+	}
+}
+
+func TestAccessTokenErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"error status", http.StatusUnauthorized, `{"error": "invalid_client"}`, "返回状态码 401"},
+		{"invalid json", http.StatusOK, `not json`, "无法解析令牌响应"},
+		{"missing access_token", http.StatusOK, `{"token_type": "bearer"}`, "令牌响应中没有 access_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTokenServer(t, func(form url.Values, count int) (int, string) {
+				return tt.status, tt.body
+			})
+			c := NewAPIClient("http://example.com", nil, 5, false, nil)
+			auth := &Auth{Type: AuthOAuth2ClientCredentials, TokenURL: server.URL, ClientID: "app"}
+
+			// 获取失败时不缓存，下次调用重新请求
+			for i := 1; i <= 2; i++ {
+				_, err := c.accessToken(context.Background(), auth)
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("accessToken() error = %v, want %q", err, tt.wantErr)
+				}
+				if got := len(server.grants()); got != i {
+					t.Errorf("token requests = %d, want %d", got, i)
+				}
+			}
+		})
+	}
+}
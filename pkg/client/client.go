@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gaoyong06/api-tester/internal/parser"
+	"github.com/gaoyong06/api-tester/pkg/utils"
 )
 
 // APIClient 是一个HTTP客户端，用于测试API端点
@@ -24,6 +26,10 @@ type APIClient struct {
 	verbose bool
 	// 请求体模板
 	requestBodies map[string]interface{}
+	// 默认请求超时时间，0 表示不限制
+	timeout time.Duration
+	// OAuth2 访问令牌缓存
+	tokens *tokenCache
 }
 
 // Response 表示API响应
@@ -32,6 +38,8 @@ type Response struct {
 	StatusCode int
 	// 响应头
 	Headers map[string][]string
+	// 响应设置的 Cookie（解析自 Set-Cookie 响应头）
+	Cookies []*http.Cookie
 	// 响应体
 	Body []byte
 	// 响应时间（毫秒）
@@ -40,6 +48,20 @@ type Response struct {
 	Error error
 }
 
+// RequestOptions 表示单次请求的附加选项
+type RequestOptions struct {
+	// 基础URL（为空时使用客户端的全局基础URL）
+	BaseURL string
+	// 请求头（在全局请求头之后设置，同名时覆盖全局值）
+	Headers map[string]string
+	// 自定义请求体
+	Body string
+	// 请求超时时间（为 0 时使用客户端的默认超时时间）
+	Timeout time.Duration
+	// 认证方式（为 nil 时不添加认证信息，设置的请求头会被 Headers 中的同名请求头覆盖）
+	Auth *Auth
+}
+
 // NewAPIClient 创建一个新的API客户端
 func NewAPIClient(baseURL string, headers map[string]string, timeout int, verbose bool, requestBodies map[string]interface{}) *APIClient {
 	// 确保基础URL以/结尾
@@ -47,31 +69,49 @@ func NewAPIClient(baseURL string, headers map[string]string, timeout int, verbos
 		baseURL += "/"
 	}
 
+	// 超时通过每个请求的 context 控制，以便单个请求覆盖默认超时时间
 	return &APIClient{
-		client: &http.Client{
-			Timeout: time.Duration(timeout) * time.Second,
-		},
-		baseURL:      baseURL,
-		headers:      headers,
-		verbose:      verbose,
+		client:        &http.Client{},
+		baseURL:       baseURL,
+		headers:       headers,
+		verbose:       verbose,
 		requestBodies: requestBodies,
+		timeout:       time.Duration(timeout) * time.Second,
+		tokens:        newTokenCache(),
 	}
 }
 
 // SendRequest 发送API请求
-func (c *APIClient) SendRequest(endpoint *parser.Endpoint, pathParams map[string]string, queryParams map[string]string, customRequestBody ...string) (*Response, error) {
+// options 可以为 nil，此时只使用客户端的全局配置；ctx 被取消时正在发送的请求会立即中止
+func (c *APIClient) SendRequest(ctx context.Context, endpoint *parser.Endpoint, pathParams map[string]string, queryParams map[string]string, options *RequestOptions) (*Response, error) {
+	if options == nil {
+		options = &RequestOptions{}
+	}
+
+	// 设置请求超时时间
+	timeout := c.timeout
+	if options.Timeout > 0 {
+		timeout = options.Timeout
+	}
+	requestCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		requestCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	// 构建URL
-	url := c.buildURL(endpoint.Path, pathParams, queryParams)
+	url := c.buildURL(options.BaseURL, endpoint.Path, pathParams, queryParams)
 
 	// 准备请求体
 	var reqBody *bytes.Buffer
 	
 	// 检查是否提供了自定义请求体
-	if len(customRequestBody) > 0 && customRequestBody[0] != "" {
+	if options.Body != "" {
 		// 使用提供的自定义请求体
-		reqBody = bytes.NewBufferString(customRequestBody[0])
+		reqBody = bytes.NewBufferString(options.Body)
 		if c.verbose {
-			fmt.Printf("使用自定义请求体: %s\n", customRequestBody[0])
+			fmt.Printf("使用自定义请求体: %s\n", utils.MaskSecrets(options.Body))
 		}
 	} else if endpoint.Method == "POST" || endpoint.Method == "PUT" || endpoint.Method == "PATCH" {
 		// 尝试从请求体模板中获取
@@ -86,7 +126,7 @@ func (c *APIClient) SendRequest(endpoint *parser.Endpoint, pathParams map[string
 				reqBody = bytes.NewBuffer(jsonData)
 				
 				if c.verbose {
-					fmt.Printf("使用请求体模板: %s\n", string(jsonData))
+					fmt.Printf("使用请求体模板: %s\n", utils.MaskSecrets(string(jsonData)))
 				}
 			}
 		}
@@ -98,7 +138,7 @@ func (c *APIClient) SendRequest(endpoint *parser.Endpoint, pathParams map[string
 	}
 
 	// 创建请求
-	req, err := http.NewRequest(endpoint.Method, url, reqBody)
+	req, err := http.NewRequestWithContext(requestCtx, endpoint.Method, url, reqBody)
 	if err != nil {
 		return &Response{Error: fmt.Errorf("创建请求失败: %v", err)}, nil
 	}
@@ -120,13 +160,25 @@ func (c *APIClient) SendRequest(endpoint *parser.Endpoint, pathParams map[string
 		}
 	}
 
+	// 添加认证信息
+	if options.Auth != nil {
+		if err := c.applyAuth(requestCtx, req, options.Auth); err != nil {
+			return &Response{Error: err}, nil
+		}
+	}
+
+	// 添加本次请求的请求头（覆盖全局请求头）
+	for key, value := range options.Headers {
+		req.Header.Set(key, value)
+	}
+
 	// 记录请求开始时间
 	startTime := time.Now()
 
 	// 发送请求
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return &Response{Error: fmt.Errorf("发送请求失败: %v", err)}, nil
+		return &Response{Error: requestError(ctx, requestCtx, timeout, err)}, nil
 	}
 	defer resp.Body.Close()
 
@@ -136,32 +188,48 @@ func (c *APIClient) SendRequest(endpoint *parser.Endpoint, pathParams map[string
 	// 读取响应体
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
+		if requestCtx.Err() != nil {
+			return &Response{Error: requestError(ctx, requestCtx, timeout, err)}, nil
+		}
 		return &Response{Error: fmt.Errorf("读取响应体失败: %v", err)}, nil
 	}
 
 	// 打印详细日志
 	if c.verbose {
-		fmt.Printf("\n> %s %s\n", endpoint.Method, url)
-		fmt.Printf("> 请求头: %v\n", req.Header)
+		fmt.Printf("\n> %s %s\n", endpoint.Method, utils.MaskSecrets(req.URL.String()))
+		fmt.Printf("> 请求头: %s\n", utils.MaskSecrets(fmt.Sprint(req.Header)))
 		if reqBody != nil && reqBody.Len() > 0 {
-			fmt.Printf("> 请求体: %s\n", reqBody.String())
+			fmt.Printf("> 请求体: %s\n", utils.MaskSecrets(reqBody.String()))
 		}
 		fmt.Printf("< 状态码: %d\n", resp.StatusCode)
-		fmt.Printf("< 响应头: %v\n", resp.Header)
-		fmt.Printf("< 响应体: %s\n", string(respBody))
+		fmt.Printf("< 响应头: %s\n", utils.MaskSecrets(fmt.Sprint(resp.Header)))
+		fmt.Printf("< 响应体: %s\n", utils.MaskSecrets(string(respBody)))
 		fmt.Printf("< 响应时间: %d ms\n", responseTime)
 	}
 
 	return &Response{
 		StatusCode:   resp.StatusCode,
 		Headers:      resp.Header,
+		Cookies:      resp.Cookies(),
 		Body:         respBody,
 		ResponseTime: responseTime,
 	}, nil
 }
 
+// requestError 生成请求失败的错误信息，区分请求超时和运行被取消
+func requestError(ctx, requestCtx context.Context, timeout time.Duration, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("请求被取消: %v", context.Cause(ctx))
+	}
+	if requestCtx.Err() != nil {
+		return fmt.Errorf("请求超时 (%v)", timeout)
+	}
+	return fmt.Errorf("发送请求失败: %v", err)
+}
+
 // buildURL 构建完整的请求URL
-func (c *APIClient) buildURL(path string, pathParams map[string]string, queryParams map[string]string) string {
+// baseURL 为空时使用客户端的全局基础URL
+func (c *APIClient) buildURL(baseURL string, path string, pathParams map[string]string, queryParams map[string]string) string {
 	// 替换路径参数
 	for name, value := range pathParams {
 		path = strings.Replace(path, "{" + name + "}", value, -1)
@@ -173,7 +241,12 @@ func (c *APIClient) buildURL(path string, pathParams map[string]string, queryPar
 	}
 
 	// 构建基础URL
-	url := c.baseURL + path
+	if baseURL == "" {
+		baseURL = c.baseURL
+	} else if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	url := baseURL + path
 
 	// 添加查询参数
 	if len(queryParams) > 0 {
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// secretMask 日志和报告中替换密钥值的字符串
+const secretMask = "******"
+
+// minSecretLength 需要屏蔽的密钥值的最小长度，过短的值屏蔽后会误伤大量普通文本
+const minSecretLength = 4
+
+// secretNamePattern 匹配看起来像密钥的变量名，如 API_KEY、db_password、authToken
+var secretNamePattern = regexp.MustCompile(`(?i)(secret|passw(or)?d|token|api_?key|access_?key|private_?key|credential|auth)`)
+
+var (
+	secretsMu sync.RWMutex
+	// 已记录的密钥值
+	secrets = make(map[string]bool)
+	// 按长度从长到短排列的密钥值，一个密钥包含另一个密钥时先屏蔽较长的，避免留下未屏蔽的片段
+	orderedSecrets []string
+)
+
+// IsSecretName 判断变量名是否像密钥
+func IsSecretName(name string) bool {
+	return secretNamePattern.MatchString(name)
+}
+
+// RegisterSecret 记录密钥值，之后 MaskSecrets 会在文本中屏蔽它
+func RegisterSecret(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	if secrets[value] {
+		return
+	}
+	secrets[value] = true
+	orderedSecrets = append(orderedSecrets, value)
+	sort.Slice(orderedSecrets, func(i, j int) bool {
+		if len(orderedSecrets[i]) != len(orderedSecrets[j]) {
+			return len(orderedSecrets[i]) > len(orderedSecrets[j])
+		}
+		return orderedSecrets[i] < orderedSecrets[j]
+	})
+}
+
+// MaskSecrets 将文本中所有已记录的密钥值替换为 ******，较长的密钥先替换
+func MaskSecrets(text string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	for _, secret := range orderedSecrets {
+		if strings.Contains(text, secret) {
+			text = strings.ReplaceAll(text, secret, secretMask)
+		}
+	}
+	return text
+}
+
+// Getenv 获取环境变量；变量名像密钥时记录它的值，以便在日志和报告中屏蔽
+func Getenv(name string) string {
+	value := os.Getenv(name)
+	if IsSecretName(name) {
+		RegisterSecret(value)
+	}
+	return value
+}
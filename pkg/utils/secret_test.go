@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaskSecretsOverlapping(t *testing.T) {
+	// 注册顺序与长度相反，结果不应依赖注册顺序或 map 的遍历顺序
+	RegisterSecret("abcd")
+	RegisterSecret("abcd1234")
+	RegisterSecret("xabcd1234y")
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"token=abcd1234", "token=******"},
+		{"token=xabcd1234y", "token=******"},
+		{"key=abcd, token=abcd1234", "key=******, token=******"},
+		{"none", "none"},
+	}
+
+	for i := 0; i < 20; i++ {
+		for _, tt := range tests {
+			got := MaskSecrets(tt.text)
+			if got != tt.want {
+				t.Fatalf("MaskSecrets(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			if strings.Contains(got, "1234") {
+				t.Fatalf("MaskSecrets(%q) leaked part of a secret: %q", tt.text, got)
+			}
+		}
+	}
+}
+
+func TestRegisterSecretIgnoresShortValues(t *testing.T) {
+	RegisterSecret("abc")
+	if got := MaskSecrets("abc"); got != "abc" {
+		t.Errorf("MaskSecrets(%q) = %q, want unchanged", "abc", got)
+	}
+}